@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Ow1Dev/NoctiFunc/internal/apikey"
+)
+
+const (
+	AppName     = "apikey"
+	APIKeysPath = "/var/lib/noctifunc/keys.yml"
+)
+
+const usage = `usage: apikey [-store path] <command> [flags]
+
+commands:
+  mint -id ID [-routes /a,/b/{id}] [-ttl 720h]   create a key and print its secret
+  revoke -id ID                                  delete a key
+  list                                           list keys`
+
+func run(_ context.Context, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet(AppName, flag.ContinueOnError)
+	storePath := fs.String("store", APIKeysPath, "path of the API key store")
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), usage) }
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("missing command\n%s", usage)
+	}
+
+	store, err := apikey.Load(*storePath)
+	if err != nil {
+		return err
+	}
+
+	cmd, cmdArgs := fs.Arg(0), fs.Args()[1:]
+	switch cmd {
+	case "mint":
+		return mint(w, store, *storePath, cmdArgs)
+	case "revoke":
+		return revoke(w, store, *storePath, cmdArgs)
+	case "list":
+		return list(w, store)
+	default:
+		return fmt.Errorf("unknown command %q\n%s", cmd, usage)
+	}
+}
+
+func mint(w io.Writer, store *apikey.Store, path string, args []string) error {
+	fs := flag.NewFlagSet("mint", flag.ContinueOnError)
+	id := fs.String("id", "", "unique ID of the key, forwarded to functions")
+	routes := fs.String("routes", "", "comma separated route paths the key may call (default all)")
+	ttl := fs.Duration("ttl", 0, "lifetime of the key (default no expiry)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var expiresAt *time.Time
+	if *ttl > 0 {
+		t := now.Add(*ttl).UTC()
+		expiresAt = &t
+	}
+
+	var allowed []string
+	if *routes != "" {
+		allowed = strings.Split(*routes, ",")
+	}
+
+	secret, _, err := store.Mint(*id, allowed, expiresAt, now)
+	if err != nil {
+		return err
+	}
+	if err := store.Save(path); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, secret)
+	return err
+}
+
+func revoke(w io.Writer, store *apikey.Store, path string, args []string) error {
+	fs := flag.NewFlagSet("revoke", flag.ContinueOnError)
+	id := fs.String("id", "", "ID of the key to revoke")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := store.Revoke(*id); err != nil {
+		return err
+	}
+	if err := store.Save(path); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "revoked %s\n", *id)
+	return err
+}
+
+func list(w io.Writer, store *apikey.Store) error {
+	for _, key := range store.Keys {
+		expires := "never"
+		if key.ExpiresAt != nil {
+			expires = key.ExpiresAt.Format(time.RFC3339)
+		}
+		routes := "*"
+		if len(key.Routes) > 0 {
+			routes = strings.Join(key.Routes, ",")
+		}
+		if _, err := fmt.Fprintf(w, "%s\texpires=%s\troutes=%s\n", key.ID, expires, routes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func main() {
+	ctx := context.Background()
+	if err := run(ctx, os.Stdout, os.Args); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
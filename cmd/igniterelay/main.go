@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -14,42 +15,200 @@ import (
 	"github.com/Ow1Dev/NoctiFunc/internal/container"
 	"github.com/Ow1Dev/NoctiFunc/internal/executer"
 	"github.com/Ow1Dev/NoctiFunc/internal/funcinvoker"
+	"github.com/Ow1Dev/NoctiFunc/internal/invocation"
 	"github.com/Ow1Dev/NoctiFunc/internal/keyservice"
 	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	serverpb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"github.com/Ow1Dev/NoctiFunc/pkg/communication"
 	"github.com/Ow1Dev/NoctiFunc/pkg/logger"
 	"github.com/Ow1Dev/NoctiFunc/pkg/utils"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 )
 
 const (
-	Version = "0.1.0"
-	AppName = "igniterelay"
-	Port    = 5001
+	Version             = "0.1.0"
+	AppName             = "igniterelay"
+	Port                = 5001
+	HealthCheckInterval = 5 * time.Second
 )
 
 type serviceServer struct {
 	pb.UnimplementedCommunicationServiceServer
-	Executer executer.Executer
+	Executer      executer.Executer
+	InvokeTimeout time.Duration // used when the caller sets no deadline
+	Invocations   *invocation.Store
+	Logger        zerolog.Logger
 }
 
 // Execute implements gateway.ServerServiceServer.
 func (s *serviceServer) Execute(ctx context.Context, r *pb.ExecuteRequest) (*pb.ExecuteResponse, error) {
-	rsp, err := s.Executer.Execute(r.GetAction(), r.GetBody(), ctx)
+	ctx = forwardRequestID(ctx)
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.InvokeTimeout)
+		defer cancel()
+	}
+
+	return s.execute(ctx, r)
+}
+
+// ExecuteStream relays the function's output to the caller chunk by chunk.
+// Without a deadline from the caller, the first chunk must arrive within the
+// request's timeout, or InvokeTimeout when it sets none.
+func (s *serviceServer) ExecuteStream(r *pb.ExecuteRequest, stream pb.CommunicationService_ExecuteStreamServer) error {
+	ctx := forwardRequestID(stream.Context())
+	received := func() {}
+	if _, ok := ctx.Deadline(); !ok {
+		timeout := s.InvokeTimeout
+		if ms := r.GetTimeoutMs(); ms > 0 {
+			timeout = time.Duration(ms) * time.Millisecond
+		}
+		var cancel context.CancelFunc
+		ctx, received, cancel = communication.WithFirstChunkTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := s.Executer.ExecuteStream(r.GetAction(), &serverpb.InvokeRequest{
+		Payload:     r.GetBody(),
+		ContentType: r.GetContentType(),
+		Http:        r.GetHttp(),
+	}, ctx, func(chunk *serverpb.InvokeChunk) error {
+		received()
+		return stream.Send(&pb.ExecuteChunk{
+			Data:        chunk.GetData(),
+			Http:        chunk.GetHttp(),
+			ContentType: chunk.GetContentType(),
+		})
+	})
+	return s.executeError(ctx, r.GetAction(), err)
+}
+
+// ExecuteAsync starts r in the background; its outcome is read with GetInvocation.
+func (s *serviceServer) ExecuteAsync(ctx context.Context, r *pb.ExecuteRequest) (*pb.AsyncInvocation, error) {
+	// The invocation outlives ctx, so only the request ID is carried over
+	id := communication.RequestID(ctx)
+	return s.Invocations.Start(r, func(ctx context.Context) (*pb.ExecuteResponse, error) {
+		if id != "" {
+			ctx = communication.WithRequestID(ctx, id)
+		}
+		return s.execute(ctx, r)
+	})
+}
+
+// GetInvocation returns the state of an invocation started with ExecuteAsync.
+func (s *serviceServer) GetInvocation(_ context.Context, r *pb.GetInvocationRequest) (*pb.AsyncInvocation, error) {
+	inv, ok := s.Invocations.Get(r.GetId())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown invocation: %s", r.GetId())
+	}
+	return inv, nil
+}
+
+// forwardRequestID passes the request ID Prism sent on to the function.
+func forwardRequestID(ctx context.Context) context.Context {
+	if id := communication.RequestID(ctx); id != "" {
+		return communication.WithRequestID(ctx, id)
+	}
+	return ctx
+}
+
+func (s *serviceServer) execute(ctx context.Context, r *pb.ExecuteRequest) (*pb.ExecuteResponse, error) {
+	rsp, err := s.Executer.Execute(r.GetAction(), &serverpb.InvokeRequest{
+		Payload:     r.GetBody(),
+		ContentType: r.GetContentType(),
+		Http:        r.GetHttp(),
+	}, ctx)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error executing command: %s\n", err)
-		return &pb.ExecuteResponse{
-			Status: "error",
-		}, nil
+		return nil, s.executeError(ctx, r.GetAction(), err)
 	}
 
 	return &pb.ExecuteResponse{
-		Status: "success",
-		Resp:   rsp,
+		Status:      "success",
+		Resp:        rsp.GetOutput(),
+		ContentType: rsp.GetContentType(),
+		Http:        rsp.GetHttp(),
 	}, nil
 }
 
+// executeError converts a failed execution into the status returned to Prism.
+func (s *serviceServer) executeError(ctx context.Context, action string, err error) error {
+	if err == nil {
+		return nil
+	}
+	s.Logger.Error().Err(err).
+		Str("request_id", communication.RequestID(ctx)).
+		Str("action", action).
+		Msg("error executing action")
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(context.Cause(ctx), context.DeadlineExceeded) {
+		return status.Errorf(codes.DeadlineExceeded, "action %s timed out", action)
+	}
+	return status.Convert(err).Err()
+}
+
+// connectionServer serves the WebSocket connection API to functions by
+// forwarding their calls to Prism, which holds the connections.
+type connectionServer struct {
+	pb.UnimplementedConnectionServiceServer
+	Prism pb.ConnectionServiceClient
+}
+
+// PostToConnection implements pb.ConnectionServiceServer.
+func (s *connectionServer) PostToConnection(ctx context.Context, r *pb.PostToConnectionRequest) (*pb.PostToConnectionResponse, error) {
+	return s.Prism.PostToConnection(ctx, r)
+}
+
+// CloseConnection implements pb.ConnectionServiceServer.
+func (s *connectionServer) CloseConnection(ctx context.Context, r *pb.CloseConnectionRequest) (*pb.CloseConnectionResponse, error) {
+	return s.Prism.CloseConnection(ctx, r)
+}
+
+// watchDocker reports igniterelay as NOT_SERVING through the gRPC health
+// service while the Docker daemon is unreachable, since no function can be
+// started then. It checks every interval until ctx is done.
+func watchDocker(ctx context.Context, healthServer *health.Server, docker *container.DockerContainer, interval time.Duration, logger zerolog.Logger) {
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	check := func() {
+		checkCtx, cancel := context.WithTimeout(ctx, interval)
+		defer cancel()
+
+		status := healthpb.HealthCheckResponse_SERVING
+		err := docker.Ping(checkCtx)
+		if err != nil {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		if status != last {
+			if err != nil {
+				logger.Warn().Err(err).Msg("reporting NOT_SERVING")
+			} else {
+				logger.Info().Msg("docker daemon is reachable, reporting SERVING")
+			}
+			last = status
+		}
+
+		healthServer.SetServingStatus("", status)
+		healthServer.SetServingStatus(pb.CommunicationService_ServiceDesc.ServiceName, status)
+	}
+
+	check()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
 func run(ctx context.Context, w io.Writer, args []string) error {
 	_ = args
 
@@ -57,6 +216,14 @@ func run(ctx context.Context, w io.Writer, args []string) error {
 	defer cancel()
 
 	debug := flag.Bool("debug", false, "sets log level to debug")
+	invokeTimeout := flag.Duration("invoke-timeout", 30*time.Second, "timeout for invocations that arrive without a deadline")
+	asyncTimeout := flag.Duration("async-timeout", 15*time.Minute, "timeout for asynchronous invocations")
+	asyncRetention := flag.Duration("async-retention", time.Hour, "how long the outcome of asynchronous invocations is kept")
+	asyncDrainTimeout := flag.Duration("async-drain-timeout", 30*time.Second, "how long shutdown waits for running asynchronous invocations before canceling them")
+	asyncMaxInvocations := flag.Int("async-max-invocations", 1000, "asynchronous invocations running or kept at once; more are rejected")
+	prismAddr := flag.String("prism-connections", "localhost:5002", "address of Prism's WebSocket connection service")
+	maxBodyBytes := flag.Int64("max-body-bytes", communication.DefaultMaxBodyBytes, "largest request or response body forwarded to functions")
+	healthInterval := flag.Duration("health-interval", HealthCheckInterval, "how often the Docker daemon is checked for the gRPC health service")
 	flag.Parse()
 
 	logger := logger.InitLog(logger.Config{
@@ -75,16 +242,43 @@ func run(ctx context.Context, w io.Writer, args []string) error {
 		os.Exit(1)
 	}
 
-	grpcFuncExecuter := funcinvoker.NewStandardGRPCClient(10 * time.Second)
+	maxMessageSize := communication.MaxMessageSize(*maxBodyBytes)
+	grpcFuncExecuter := funcinvoker.NewStandardGRPCClient(maxMessageSize)
 	fileKeyService := keyservice.NewFileSystemKeyService("/var/lib/noctifunc/action")
 
 	executer := executer.NewExecuter(dockerRunner, fileKeyService, grpcFuncExecuter, *logger.GetLogger())
 
-	s := grpc.NewServer()
+	s := grpc.NewServer(
+		grpc.MaxRecvMsgSize(maxMessageSize),
+		grpc.MaxSendMsgSize(maxMessageSize),
+	)
+	// Invocations outlive the signal so shutdown can let them finish
+	invocations := invocation.NewStore(context.WithoutCancel(ctx), *asyncTimeout, *asyncRetention, *asyncMaxInvocations)
 	pb.RegisterCommunicationServiceServer(s, &serviceServer{
-		Executer: *executer,
+		Executer:      *executer,
+		InvokeTimeout: *invokeTimeout,
+		Invocations:   invocations,
+		Logger:        *logger.GetLogger(),
+	})
+
+	prismConn, err := grpc.NewClient(*prismAddr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(maxMessageSize)),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating prism client: %w", err)
+	}
+	defer func() {
+		_ = prismConn.Close()
+	}()
+	pb.RegisterConnectionServiceServer(s, &connectionServer{
+		Prism: pb.NewConnectionServiceClient(prismConn),
 	})
 
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(s, healthServer)
+	go watchDocker(ctx, healthServer, dockerRunner, *healthInterval, *logger.GetLogger())
+
 	go func() {
 		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", Port))
 		if err != nil {
@@ -103,9 +297,15 @@ func run(ctx context.Context, w io.Writer, args []string) error {
 		defer wg.Done()
 		<-ctx.Done()
 		defer cancel()
+		// Tell health checkers to stop sending work before draining
+		healthServer.Shutdown()
 		s.GracefulStop()
 	}()
 	wg.Wait()
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), *asyncDrainTimeout)
+	defer drainCancel()
+	invocations.Shutdown(drainCtx)
 	return nil
 }
 
@@ -12,17 +12,25 @@ import (
 	"sync"
 	"time"
 
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
 	"github.com/Ow1Dev/NoctiFunc/pkg/communication"
 	"github.com/Ow1Dev/NoctiFunc/pkg/logger"
 	"github.com/Ow1Dev/NoctiFunc/pkg/prism"
 	"github.com/Ow1Dev/NoctiFunc/pkg/utils"
 	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
 )
 
 const (
-	Version = "0.1.0"
-	AppName = "prism"
-	Port    = 5000
+	Version              = "0.1.0"
+	AppName              = "prism"
+	Port                 = 5000
+	ConnectionsAddr      = "localhost:5002"
+	AdminAddr            = "localhost:5003"
+	MetricsAddr          = "localhost:9090"
+	RoutesPath           = "/var/lib/noctifunc/routes"
+	RoutesReloadInterval = 2 * time.Second
+	APIKeysPath          = "/var/lib/noctifunc/keys.yml"
 )
 
 func run(ctx context.Context, w io.Writer, args []string) error {
@@ -32,8 +40,23 @@ func run(ctx context.Context, w io.Writer, args []string) error {
 	defer cancel()
 
 	debug := flag.Bool("debug", false, "sets log level to debug")
+	timeout := flag.Duration("timeout", prism.DefaultTimeout, "default invocation timeout for routes without timeout")
+	jwks := flag.String("jwks", "", "JWKS file path or URL used to verify tokens on jwt routes")
+	cacheBytes := flag.Int64("cache-bytes", prism.DefaultCacheBytes, "memory available to cached responses")
+	maxBodyBytes := flag.Int64("max-body-bytes", communication.DefaultMaxBodyBytes, "default request body limit for routes without max_body_bytes")
+	routesPath := flag.String("routes", RoutesPath, "directory holding the route files")
+	apiKeysPath := flag.String("api-keys", APIKeysPath, "API key store checked on api_key routes")
+	openAPIPath := flag.String("openapi", "", "write the OpenAPI document for the routes to this file (- for stdout) and exit")
+	apiTitle := flag.String("api-title", prism.DefaultAPITitle, "title of the OpenAPI document")
+	apiVersion := flag.String("api-version", prism.DefaultAPIVersion, "version of the OpenAPI document")
+	adminAddr := flag.String("admin-addr", AdminAddr, "address of the admin API, served when PRISM_ADMIN_TOKEN is set")
+	metricsAddr := flag.String("metrics-addr", MetricsAddr, "address of the /metrics listener, empty to disable metrics")
 	flag.Parse()
 
+	if *openAPIPath != "" {
+		return writeOpenAPI(w, *openAPIPath, *routesPath, prism.WithAPIInfo(*apiTitle, *apiVersion))
+	}
+
 	logger := logger.InitLog(logger.Config{
 		Writer:        w,
 		Level:         utils.Ternary(*debug, zerolog.DebugLevel, zerolog.InfoLevel),
@@ -46,15 +69,84 @@ func run(ctx context.Context, w io.Writer, args []string) error {
 
 	// Create dependencies
 	fileReader := &prism.OSFileReader{}
-	grpcClient := communication.NewGRPCClient("localhost:5001", time.Second)
+	grpcClient := communication.NewGRPCClient("localhost:5001", communication.MaxMessageSize(*maxBodyBytes))
 
 	// Create server
-	srv := prism.NewServer(grpcClient, fileReader, "/var/lib/noctifunc/routes", *logger.GetLogger())
+	opts := []prism.Option{
+		prism.WithMaxBodyBytes(*maxBodyBytes),
+		prism.WithTimeout(*timeout),
+		prism.WithAPIKeyStore(*apiKeysPath),
+		prism.WithCacheBytes(*cacheBytes),
+		prism.WithAPIInfo(*apiTitle, *apiVersion),
+		prism.WithFileWriter(&prism.OSFileWriter{}),
+	}
+	adminToken := os.Getenv("PRISM_ADMIN_TOKEN")
+	if adminToken != "" {
+		opts = append(opts, prism.WithAdminToken(adminToken))
+	}
+	if *jwks != "" {
+		opts = append(opts, prism.WithJWKS(*jwks))
+	}
+	srv := prism.NewServer(grpcClient, fileReader, *routesPath, *logger.GetLogger(), opts...)
+	if err := srv.ReloadRoutes(); err != nil {
+		logger.GetLogger().Error().Err(err).Msg("failed to load routes")
+	}
+	if err := srv.ReloadAPIKeys(); err != nil {
+		logger.GetLogger().Error().Err(err).Msg("failed to load API keys")
+	}
+	go srv.WatchRoutes(ctx, RoutesReloadInterval)
+
+	// Metrics are only served on their own listener so they are never public
+	var metricsServer *http.Server
+	if *metricsAddr != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("GET /metrics", srv.MetricsHandler())
+		metricsServer = &http.Server{Addr: *metricsAddr, Handler: metricsMux}
+		go func() {
+			logger.GetLogger().Info().Msgf("metrics listening on %s", metricsServer.Addr)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fmt.Fprintf(os.Stderr, "error serving metrics: %s\n", err)
+			}
+		}()
+	}
 
 	httpServer := &http.Server{
 		Addr:    net.JoinHostPort("0.0.0.0", fmt.Sprintf("%d", Port)),
 		Handler: srv.Handler(),
 	}
+	httpServer.RegisterOnShutdown(srv.CloseConnections)
+
+	// igniterelay posts to WebSocket connections on behalf of functions through this server
+	connServer := grpc.NewServer(
+		grpc.MaxRecvMsgSize(communication.MaxMessageSize(*maxBodyBytes)),
+	)
+	commpb.RegisterConnectionServiceServer(connServer, srv.ConnectionService())
+	go func() {
+		lis, err := net.Listen("tcp", ConnectionsAddr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error listening for connection service: %s\n", err)
+			return
+		}
+		logger.GetLogger().Info().Msgf("connection service listening on %s", lis.Addr())
+		if err := connServer.Serve(lis); err != nil {
+			fmt.Fprintf(os.Stderr, "error serving connection service: %s\n", err)
+		}
+	}()
+
+	// The admin API manages routes, so it is only served on its own listener
+	var adminServer *http.Server
+	if adminToken != "" {
+		adminServer = &http.Server{
+			Addr:    *adminAddr,
+			Handler: srv.AdminHandler(),
+		}
+		go func() {
+			logger.GetLogger().Info().Msgf("admin API listening on %s", adminServer.Addr)
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fmt.Fprintf(os.Stderr, "error serving admin API: %s\n", err)
+			}
+		}()
+	}
 
 	go func() {
 		logger.GetLogger().Info().Msgf("prim server listening on %s", httpServer.Addr)
@@ -74,11 +166,46 @@ func run(ctx context.Context, w io.Writer, args []string) error {
 		if err := httpServer.Shutdown(shutdownCtx); err != nil {
 			fmt.Fprintf(os.Stderr, "error shutting down http server: %s\n", err)
 		}
+		if metricsServer != nil {
+			if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+				fmt.Fprintf(os.Stderr, "error shutting down metrics server: %s\n", err)
+			}
+		}
+		if adminServer != nil {
+			if err := adminServer.Shutdown(shutdownCtx); err != nil {
+				fmt.Fprintf(os.Stderr, "error shutting down admin server: %s\n", err)
+			}
+		}
+		connServer.GracefulStop()
 	}()
 	wg.Wait()
 	return nil
 }
 
+// writeOpenAPI writes the OpenAPI document for the routes in routesPath to
+// path, or to w when path is "-". Invalid route files are skipped as they are
+// when serving, and reported on stderr.
+func writeOpenAPI(w io.Writer, path, routesPath string, opts ...prism.Option) error {
+	logger := zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).Level(zerolog.WarnLevel)
+	srv := prism.NewServer(nil, &prism.OSFileReader{}, routesPath, logger, opts...)
+	if err := srv.ReloadRoutes(); err != nil {
+		return err
+	}
+
+	data, err := srv.OpenAPI()
+	if err != nil {
+		return err
+	}
+	if path == "-" {
+		_, err = w.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("error writing OpenAPI document: %w", err)
+	}
+	return nil
+}
+
 func main() {
 	ctx := context.Background()
 	if err := run(ctx, os.Stdout, os.Args); err != nil {
@@ -19,7 +19,7 @@
         ...
       }: let
         name = "NoctiFunc";
-        vendorHash = "sha256-int+VOY11p/Vg/ZVKf2I37AejHvH0EUZJoW+U8EFVbQ=";
+        vendorHash = "sha256-ZvGONoKJZWT1ObDUZxwQNhcLofkKXYhyIVz4bK9cI2E=";
       in {
         devShells = {
           default = pkgs.mkShell {
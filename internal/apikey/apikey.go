@@ -0,0 +1,181 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrUnknownKey = errors.New("unknown API key")
+	ErrExpiredKey = errors.New("API key has expired")
+	ErrKeyExists  = errors.New("API key ID already exists")
+	ErrNotFound   = errors.New("API key ID not found")
+)
+
+// secretPrefix makes minted keys easy to recognise in logs and secret scanners.
+const secretPrefix = "nfk_"
+
+// Key is an entry in the key store. Only the SHA-256 hash of the secret is
+// stored, so a leaked key store does not leak usable keys.
+type Key struct {
+	ID        string     `yaml:"id"`
+	Hash      string     `yaml:"hash"`
+	Routes    []string   `yaml:"routes,omitempty"` // route paths the key may call; empty allows all
+	CreatedAt time.Time  `yaml:"created_at"`
+	ExpiresAt *time.Time `yaml:"expires_at,omitempty"`
+}
+
+// AllowsRoute reports whether the key may call the route served on path,
+// which is the route's path template such as /users/{id}.
+func (k *Key) AllowsRoute(path string) bool {
+	return len(k.Routes) == 0 || slices.Contains(k.Routes, path)
+}
+
+// Expired reports whether the key has expired at now.
+func (k *Key) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// Store is the set of API keys Prism accepts.
+type Store struct {
+	Keys []*Key `yaml:"keys"`
+
+	byHash map[string]*Key
+}
+
+// Parse decodes a key store file. IDs and hashes must be unique, so
+// revoking a key by its ID revokes exactly one secret.
+func Parse(data []byte) (*Store, error) {
+	var s Store
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("error parsing key store: %w", err)
+	}
+
+	s.byHash = make(map[string]*Key, len(s.Keys))
+	ids := make(map[string]bool, len(s.Keys))
+	for _, key := range s.Keys {
+		if key.ID == "" || key.Hash == "" {
+			return nil, fmt.Errorf("key store entries need an id and a hash")
+		}
+		if ids[key.ID] {
+			return nil, fmt.Errorf("key store has duplicate id %q", key.ID)
+		}
+		if _, ok := s.byHash[key.Hash]; ok {
+			return nil, fmt.Errorf("key store has duplicate hash for id %q", key.ID)
+		}
+		ids[key.ID] = true
+		s.byHash[key.Hash] = key
+	}
+	return &s, nil
+}
+
+// Load reads the key store at path. A missing file is an empty store.
+func Load(path string) (*Store, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Parse(nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading key store: %w", err)
+	}
+	return Parse(data)
+}
+
+// Save atomically replaces the key store at path.
+func (s *Store) Save(path string) error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("error encoding key store: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".keys-*")
+	if err != nil {
+		return fmt.Errorf("error writing key store: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("error writing key store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing key store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error writing key store: %w", err)
+	}
+	return nil
+}
+
+// Lookup returns the key matching secret.
+func (s *Store) Lookup(secret string, now time.Time) (*Key, error) {
+	key, ok := s.byHash[hashSecret(secret)]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if key.Expired(now) {
+		return nil, ErrExpiredKey
+	}
+	return key, nil
+}
+
+// Mint adds a new key and returns its secret. The secret is not stored and
+// cannot be recovered later.
+func (s *Store) Mint(id string, routes []string, expiresAt *time.Time, now time.Time) (string, *Key, error) {
+	if id == "" {
+		return "", nil, fmt.Errorf("key ID is required")
+	}
+	if slices.ContainsFunc(s.Keys, func(k *Key) bool { return k.ID == id }) {
+		return "", nil, fmt.Errorf("%w: %s", ErrKeyExists, id)
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("error generating key: %w", err)
+	}
+	secret := secretPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	key := &Key{
+		ID:        id,
+		Hash:      hashSecret(secret),
+		Routes:    routes,
+		CreatedAt: now.UTC(),
+		ExpiresAt: expiresAt,
+	}
+	s.Keys = append(s.Keys, key)
+	if s.byHash == nil {
+		s.byHash = make(map[string]*Key)
+	}
+	s.byHash[key.Hash] = key
+
+	return secret, key, nil
+}
+
+// Revoke removes the key with the given ID.
+func (s *Store) Revoke(id string) error {
+	i := slices.IndexFunc(s.Keys, func(k *Key) bool { return k.ID == id })
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	delete(s.byHash, s.Keys[i].Hash)
+	s.Keys = slices.Delete(s.Keys, i, i+1)
+	return nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,114 @@
+package apikey
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore_MintAndLookup(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store, _ := Parse(nil)
+
+	secret, key, err := store.Mint("ci", []string{"/orders"}, nil, now)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(secret, secretPrefix) {
+		t.Errorf("Expected secret to start with %s, got %s", secretPrefix, secret)
+	}
+	if strings.Contains(key.Hash, secret) {
+		t.Error("Expected the secret not to be stored")
+	}
+
+	got, err := store.Lookup(secret, now)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.ID != "ci" {
+		t.Errorf("Expected key 'ci', got '%s'", got.ID)
+	}
+
+	if _, err := store.Lookup("nfk_wrong", now); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey, got %v", err)
+	}
+
+	if _, _, err := store.Mint("ci", nil, nil, now); !errors.Is(err, ErrKeyExists) {
+		t.Errorf("Expected ErrKeyExists, got %v", err)
+	}
+}
+
+func TestStore_Expiry(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(time.Hour)
+	store, _ := Parse(nil)
+
+	secret, _, err := store.Mint("temp", nil, &expiresAt, now)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := store.Lookup(secret, now.Add(time.Minute)); err != nil {
+		t.Errorf("Expected key to be valid, got %v", err)
+	}
+	if _, err := store.Lookup(secret, expiresAt); !errors.Is(err, ErrExpiredKey) {
+		t.Errorf("Expected ErrExpiredKey, got %v", err)
+	}
+}
+
+func TestStore_Revoke(t *testing.T) {
+	now := time.Now()
+	store, _ := Parse(nil)
+
+	secret, _, _ := store.Mint("ci", nil, nil, now)
+	if err := store.Revoke("ci"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := store.Lookup(secret, now); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected revoked key to be unknown, got %v", err)
+	}
+	if err := store.Revoke("ci"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestStore_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.yml")
+	now := time.Now()
+
+	store, err := Load(path)
+	if err != nil {
+		t.Fatalf("Expected missing store to load empty, got %v", err)
+	}
+	secret, _, _ := store.Mint("ci", []string{"/orders", "/users/{id}"}, nil, now)
+	if err := store.Save(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	key, err := loaded.Lookup(secret, now)
+	if err != nil {
+		t.Fatalf("Expected saved key to be found, got %v", err)
+	}
+	if !key.AllowsRoute("/users/{id}") || key.AllowsRoute("/admin") {
+		t.Errorf("Unexpected allowed routes: %v", key.Routes)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"key without hash": "keys:\n  - id: ci\n",
+		"duplicate id":     "keys:\n  - {id: ci, hash: aa}\n  - {id: ci, hash: bb}\n",
+		"duplicate hash":   "keys:\n  - {id: ci, hash: aa}\n  - {id: deploy, hash: aa}\n",
+	}
+	for name, data := range tests {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Expected error for %s, got nil", name)
+		}
+	}
+}
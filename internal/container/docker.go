@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/client"
@@ -16,6 +17,7 @@ import (
 	dockernet "github.com/docker/docker/api/types/network"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 
+	"github.com/Ow1Dev/NoctiFunc/pkg/communication"
 	"github.com/Ow1Dev/NoctiFunc/pkg/network"
 )
 
@@ -24,6 +26,7 @@ type DockerClientInterface interface {
 	ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error
 	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig,
 		networkingConfig *dockernet.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error)
+	Ping(ctx context.Context) (types.Ping, error)
 }
 
 type DockerContainer struct {
@@ -43,6 +46,7 @@ type DockerConfig struct {
 	ContainerReadyTimeout time.Duration
 	ConnectionTimeout     time.Duration
 	RetryInterval         time.Duration
+	RelayAddress          string // igniterelay address as seen from inside the container
 }
 
 func DefaultDockerConfig() DockerConfig {
@@ -54,6 +58,7 @@ func DefaultDockerConfig() DockerConfig {
 		ContainerReadyTimeout: 30 * time.Second,
 		ConnectionTimeout:     time.Second,
 		RetryInterval:         time.Second,
+		RelayAddress:          "host.docker.internal:5001",
 	}
 }
 
@@ -109,10 +114,22 @@ func (d *DockerClientAdapter) ContainerCreate(ctx context.Context, config *conta
 	return d.cli.ContainerCreate(ctx, config, hostConfig, networkingConfig, platform, containerName)
 }
 
+func (d *DockerClientAdapter) Ping(ctx context.Context) (types.Ping, error) {
+	return d.cli.Ping(ctx)
+}
+
 type DockerClientAdapter struct {
 	cli *client.Client
 }
 
+// Ping checks that the Docker daemon is reachable.
+func (d *DockerContainer) Ping(ctx context.Context) error {
+	if _, err := d.cli.Ping(ctx); err != nil {
+		return fmt.Errorf("docker daemon is unreachable: %w", err)
+	}
+	return nil
+}
+
 func (d *DockerContainer) WaitForContainer(key string, ctx context.Context) error {
 	d.logger.Info().Msgf("Waiting for container to be ready: %s", key)
 
@@ -249,10 +266,13 @@ func (d *DockerContainer) create(key string, ctx context.Context) (string, error
 	resp, err := d.cli.ContainerCreate(ctx, &container.Config{
 		Image: d.config.Image,
 		Cmd:   []string{"/func/main"},
+		Env:   []string{communication.RelayAddrEnv + "=" + d.config.RelayAddress},
 		ExposedPorts: nat.PortSet{
 			internalPort: struct{}{},
 		},
 	}, &container.HostConfig{
+		// Lets functions reach igniterelay on the host, which Docker Desktop provides on its own
+		ExtraHosts: []string{"host.docker.internal:host-gateway"},
 		PortBindings: nat.PortMap{
 			internalPort: []nat.PortBinding{
 				{
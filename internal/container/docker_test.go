@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"net"
+	"slices"
 	"testing"
 	"time"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	dockernet "github.com/docker/docker/api/types/network"
 	"github.com/docker/go-connections/nat"
@@ -23,6 +25,7 @@ type MockDockerClient struct {
 	containerStartFunc   func(ctx context.Context, containerID string, options container.StartOptions) error
 	containerCreateFunc  func(ctx context.Context, config *container.Config, hostConfig *container.HostConfig,
 		networkingConfig *dockernet.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error)
+	pingFunc func(ctx context.Context) (types.Ping, error)
 }
 
 func (m *MockDockerClient) ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error) {
@@ -48,6 +51,13 @@ func (m *MockDockerClient) ContainerCreate(ctx context.Context, config *containe
 	return container.CreateResponse{ID: "mock-container-id"}, nil
 }
 
+func (m *MockDockerClient) Ping(ctx context.Context) (types.Ping, error) {
+	if m.pingFunc != nil {
+		return m.pingFunc(ctx)
+	}
+	return types.Ping{APIVersion: "1.50"}, nil
+}
+
 type MockTimeProvider struct {
 	sleepFunc   func(duration time.Duration)
 	nowFunc     func() time.Time
@@ -190,6 +200,12 @@ func TestDockerContainer_start_Success(t *testing.T) {
 		containerCreateFunc: func(ctx context.Context, config *container.Config, hostConfig *container.HostConfig,
 			networkingConfig *dockernet.NetworkingConfig, platform *ocispec.Platform, containerName string,
 		) (container.CreateResponse, error) {
+			if !slices.Contains(config.Env, "NOCTIFUNC_RELAY_ADDR=host.docker.internal:5001") {
+				t.Errorf("Expected relay address in env, got %v", config.Env)
+			}
+			if !slices.Contains(hostConfig.ExtraHosts, "host.docker.internal:host-gateway") {
+				t.Errorf("Expected host.docker.internal in extra hosts, got %v", hostConfig.ExtraHosts)
+			}
 			return container.CreateResponse{ID: "new-container-id"}, nil
 		},
 	}
@@ -432,3 +448,20 @@ func TestDefaultDockerConfig(t *testing.T) {
 		t.Errorf("Expected timeout 30s, got %v", config.ContainerReadyTimeout)
 	}
 }
+
+func TestDockerContainer_Ping(t *testing.T) {
+	mockClient := &MockDockerClient{}
+	dockerContainer := NewDockerContainer(mockClient, &netpkg.MockPortAllocator{}, &netpkg.MockNetwork{}, &MockTimeProvider{}, DefaultDockerConfig(), zerolog.Nop())
+
+	if err := dockerContainer.Ping(context.Background()); err != nil {
+		t.Errorf("expected a reachable daemon, got %v", err)
+	}
+
+	daemonErr := errors.New("connection refused")
+	mockClient.pingFunc = func(ctx context.Context) (types.Ping, error) {
+		return types.Ping{}, daemonErr
+	}
+	if err := dockerContainer.Ping(context.Background()); !errors.Is(err, daemonErr) {
+		t.Errorf("expected the daemon error, got %v", err)
+	}
+}
@@ -2,14 +2,21 @@ package executer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"strconv"
 
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"github.com/Ow1Dev/NoctiFunc/pkg/communication"
 	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type GRPCFuncExecuter interface {
-	Invoke(ctx context.Context, url, payload string) (string, error)
+	Invoke(ctx context.Context, url string, req *pb.InvokeRequest) (*pb.InvokeResult, error)
+	InvokeStream(ctx context.Context, url string, req *pb.InvokeRequest, send func(*pb.InvokeChunk) error) error
 }
 
 type KeyService interface {
@@ -38,34 +45,80 @@ func NewExecuter(container Container, keyService KeyService, grpcFuncExecuter GR
 	}
 }
 
-func (e *Executer) Execute(action, body string, ctx context.Context) (string, error) {
+// Execute invokes action with req. Failures are returned as gRPC status
+// errors: NotFound for unknown actions, Unavailable when the function cannot
+// be started and DeadlineExceeded when ctx expires. Errors raised by the
+// function keep the status it returned.
+func (e *Executer) Execute(action string, req *pb.InvokeRequest, ctx context.Context) (*pb.InvokeResult, error) {
+	url, err := e.functionURL(action, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	e.log(ctx).Info().Msgf("Making request to %s", url)
+	rsp, err := e.grpcFuncExecuter.Invoke(ctx, url, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to handle request: %w", err)
+	}
+
+	return rsp, nil
+}
+
+// ExecuteStream invokes action with req like Execute, passing the function's
+// output to send chunk by chunk as it is produced.
+func (e *Executer) ExecuteStream(action string, req *pb.InvokeRequest, ctx context.Context, send func(*pb.InvokeChunk) error) error {
+	url, err := e.functionURL(action, ctx)
+	if err != nil {
+		return err
+	}
+
+	e.log(ctx).Info().Msgf("Making streaming request to %s", url)
+	if err := e.grpcFuncExecuter.InvokeStream(ctx, url, req, send); err != nil {
+		return fmt.Errorf("failed to handle request: %w", err)
+	}
+	return nil
+}
+
+// functionURL returns the address of the function serving action, starting
+// its container first if it is not running.
+func (e *Executer) functionURL(action string, ctx context.Context) (string, error) {
 	key, err := e.keyService.GetKeyFromAction(action)
 	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", status.Errorf(codes.NotFound, "unknown action: %s", action)
+		}
 		return "", fmt.Errorf("failed to get key from action: %w", err)
 	}
 
-	var port int
 	if !e.container.IsRunning(key, ctx) {
-		e.logger.Info().Msgf("Container is not running, starting new container with key: %s", key)
+		e.log(ctx).Info().Msgf("Container is not running, starting new container with key: %s", key)
 		err = e.container.Start(key, ctx)
 		if err != nil {
-			return "", fmt.Errorf("failed to start container: %w", err)
+			if ctx.Err() == context.DeadlineExceeded {
+				return "", status.Errorf(codes.DeadlineExceeded, "timed out starting container: %s", key)
+			}
+			return "", status.Errorf(codes.Unavailable, "failed to start container: %v", err)
 		}
 	}
 
-	e.logger.Debug().Msgf("Container already exists, getting port for key: %s", key)
-	port = e.container.GetPort(key, ctx)
+	e.log(ctx).Debug().Msgf("Container already exists, getting port for key: %s", key)
+	port := e.container.GetPort(key, ctx)
 
 	if port == 0 {
-		return "", fmt.Errorf("failed to get port for container: %s", key)
+		return "", status.Errorf(codes.Unavailable, "failed to get port for container: %s", key)
 	}
 
 	// TODO: get url from configuration or environment variable
-	e.logger.Info().Msgf("Making request to localhost:%d", port)
-	rsp, err := e.grpcFuncExecuter.Invoke(ctx, "localhost:"+strconv.Itoa(port), body)
-	if err != nil {
-		return "", fmt.Errorf("failed to handle request: %w", err)
-	}
+	return "localhost:" + strconv.Itoa(port), nil
+}
 
-	return rsp, nil
+// log returns the logger for the invocation ctx belongs to, which adds the
+// ID of the request that caused it.
+func (e *Executer) log(ctx context.Context) *zerolog.Logger {
+	id := communication.RequestID(ctx)
+	if id == "" {
+		return &e.logger
+	}
+	logger := e.logger.With().Str("request_id", id).Logger()
+	return &logger
 }
@@ -3,9 +3,14 @@ package executer
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"testing"
+	"time"
 
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
 	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type MockContainer struct {
@@ -47,14 +52,22 @@ func (m *MockKeyService) GetKeyFromAction(action string) (string, error) {
 }
 
 type MockGRPCFuncExecuter struct {
-	invokeFunc func(ctx context.Context, url, payload string) (string, error)
+	invokeFunc       func(ctx context.Context, url string, req *pb.InvokeRequest) (*pb.InvokeResult, error)
+	invokeStreamFunc func(ctx context.Context, url string, req *pb.InvokeRequest, send func(*pb.InvokeChunk) error) error
 }
 
-func (m *MockGRPCFuncExecuter) Invoke(ctx context.Context, url, payload string) (string, error) {
+func (m *MockGRPCFuncExecuter) Invoke(ctx context.Context, url string, req *pb.InvokeRequest) (*pb.InvokeResult, error) {
 	if m.invokeFunc != nil {
-		return m.invokeFunc(ctx, url, payload)
+		return m.invokeFunc(ctx, url, req)
 	}
-	return "mocked response", nil
+	return &pb.InvokeResult{Output: []byte("mocked response")}, nil
+}
+
+func (m *MockGRPCFuncExecuter) InvokeStream(ctx context.Context, url string, req *pb.InvokeRequest, send func(*pb.InvokeChunk) error) error {
+	if m.invokeStreamFunc != nil {
+		return m.invokeStreamFunc(ctx, url, req, send)
+	}
+	return send(&pb.InvokeChunk{Data: []byte("mocked response")})
 }
 
 // Test cases
@@ -76,20 +89,20 @@ func TextExecuter_Execute_Success_ContainerRunning(t *testing.T) {
 	}
 
 	mockGRPCFuncExecuter := &MockGRPCFuncExecuter{
-		invokeFunc: func(ctx context.Context, url, payload string) (string, error) {
-			return "mocked response", nil
+		invokeFunc: func(ctx context.Context, url string, req *pb.InvokeRequest) (*pb.InvokeResult, error) {
+			return &pb.InvokeResult{Output: []byte("mocked response")}, nil
 		},
 	}
 
 	executer := NewExecuter(mockContainer, mockKeyService, mockGRPCFuncExecuter, zerolog.Nop())
 
-	result, err := executer.Execute("test-action", "test-body", ctx)
+	result, err := executer.Execute("test-action", &pb.InvokeRequest{Payload: []byte("test-body")}, ctx)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
 
-	if result != "mocked response" {
-		t.Errorf("Expected 'success response', got %s", result)
+	if string(result.GetOutput()) != "mocked response" {
+		t.Errorf("Expected 'success response', got %s", result.GetOutput())
 	}
 }
 
@@ -114,20 +127,20 @@ func TestExecuter_Execute_Success_ContainerNotRunning(t *testing.T) {
 	}
 
 	mockGRPCFuncExecuter := &MockGRPCFuncExecuter{
-		invokeFunc: func(ctx context.Context, url, payload string) (string, error) {
-			return "mocked response", nil
+		invokeFunc: func(ctx context.Context, url string, req *pb.InvokeRequest) (*pb.InvokeResult, error) {
+			return &pb.InvokeResult{Output: []byte("mocked response")}, nil
 		},
 	}
 
 	executer := NewExecuter(mockContainer, mockKeyService, mockGRPCFuncExecuter, zerolog.Nop())
 
-	response, err := executer.Execute("test-action", "test-body", ctx)
+	response, err := executer.Execute("test-action", &pb.InvokeRequest{Payload: []byte("test-body")}, ctx)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
 
-	if response != "mocked response" {
-		t.Errorf("Expected 'mocked response', got %s", response)
+	if string(response.GetOutput()) != "mocked response" {
+		t.Errorf("Expected 'mocked response', got %s", response.GetOutput())
 	}
 }
 
@@ -155,12 +168,27 @@ func TestExecuter_Execute_FileReaderError(t *testing.T) {
 
 	executer := NewExecuter(mockContainer, mockKeyService, mockGRPCFuncExecuter, zerolog.Nop())
 
-	response, err := executer.Execute("test-action", "test-body", ctx)
-	if err == nil || response != "" {
+	response, err := executer.Execute("test-action", &pb.InvokeRequest{Payload: []byte("test-body")}, ctx)
+	if err == nil || response != nil {
 		t.Errorf("Expected file read error, got %v", err)
 	}
 }
 
+func TestExecuter_Execute_UnknownAction(t *testing.T) {
+	mockKeyService := &MockKeyService{
+		GetKeyFromActionFunc: func(action string) (string, error) {
+			return "", fmt.Errorf("failed to open action file: %w", fs.ErrNotExist)
+		},
+	}
+
+	executer := NewExecuter(&MockContainer{}, mockKeyService, &MockGRPCFuncExecuter{}, zerolog.Nop())
+
+	_, err := executer.Execute("missing-action", &pb.InvokeRequest{}, context.Background())
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected code %s, got %s (%v)", codes.NotFound, status.Code(err), err)
+	}
+}
+
 func TestExecuter_Execute_ContainerStartError(t *testing.T) {
 	ctx := context.Background()
 	mockContainer := &MockContainer{
@@ -185,10 +213,13 @@ func TestExecuter_Execute_ContainerStartError(t *testing.T) {
 
 	executer := NewExecuter(mockContainer, mockKeyService, mockGRPCFuncExecuter, zerolog.Nop())
 
-	response, err := executer.Execute("test-action", "test-body", ctx)
-	if err == nil || response != "" {
+	response, err := executer.Execute("test-action", &pb.InvokeRequest{Payload: []byte("test-body")}, ctx)
+	if err == nil || response != nil {
 		t.Errorf("Expected container start error, got %v", err)
 	}
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected code %s, got %s", codes.Unavailable, status.Code(err))
+	}
 }
 
 func TestExecuter_Execute_PortZeroError(t *testing.T) {
@@ -215,10 +246,13 @@ func TestExecuter_Execute_PortZeroError(t *testing.T) {
 
 	executer := NewExecuter(mockContainer, mockKeyService, mockGRPCFuncExecuter, zerolog.Nop())
 
-	response, err := executer.Execute("test-action", "test-body", ctx)
-	if err == nil || response != "" {
+	response, err := executer.Execute("test-action", &pb.InvokeRequest{Payload: []byte("test-body")}, ctx)
+	if err == nil || response != nil {
 		t.Errorf("Expected port zero error, got %v", err)
 	}
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected code %s, got %s", codes.Unavailable, status.Code(err))
+	}
 }
 
 func TestExecuter_Execute_GRPCFuncExecuter(t *testing.T) {
@@ -239,15 +273,156 @@ func TestExecuter_Execute_GRPCFuncExecuter(t *testing.T) {
 	}
 
 	mockGRPCFuncExecuter := &MockGRPCFuncExecuter{
-		invokeFunc: func(ctx context.Context, url, payload string) (string, error) {
-			return "", fmt.Errorf("gRPC client error")
+		invokeFunc: func(ctx context.Context, url string, req *pb.InvokeRequest) (*pb.InvokeResult, error) {
+			return nil, fmt.Errorf("gRPC client error")
 		},
 	}
 
 	executer := NewExecuter(mockContainer, mockKeyService, mockGRPCFuncExecuter, zerolog.Nop())
 
-	response, err := executer.Execute("test-action", "test-body", ctx)
-	if err == nil || response != "" {
+	response, err := executer.Execute("test-action", &pb.InvokeRequest{Payload: []byte("test-body")}, ctx)
+	if err == nil || response != nil {
 		t.Errorf("Expected gRPC client error, got %v", err)
 	}
 }
+
+func TestExecuter_Execute_KeepsFunctionStatus(t *testing.T) {
+	mockContainer := &MockContainer{
+		IsRunningFunc: func(key string, ctx context.Context) bool {
+			return true
+		},
+	}
+
+	mockGRPCFuncExecuter := &MockGRPCFuncExecuter{
+		invokeFunc: func(ctx context.Context, url string, req *pb.InvokeRequest) (*pb.InvokeResult, error) {
+			return nil, status.Error(codes.InvalidArgument, "bad input")
+		},
+	}
+
+	executer := NewExecuter(mockContainer, &MockKeyService{}, mockGRPCFuncExecuter, zerolog.Nop())
+
+	_, err := executer.Execute("test-action", &pb.InvokeRequest{}, context.Background())
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected code %s, got %s", codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestExecuter_Execute_ForwardsRequest(t *testing.T) {
+	ctx := context.Background()
+	mockContainer := &MockContainer{
+		IsRunningFunc: func(key string, ctx context.Context) bool {
+			return true
+		},
+	}
+
+	var gotReq *pb.InvokeRequest
+	mockGRPCFuncExecuter := &MockGRPCFuncExecuter{
+		invokeFunc: func(ctx context.Context, url string, req *pb.InvokeRequest) (*pb.InvokeResult, error) {
+			gotReq = req
+			return &pb.InvokeResult{Output: []byte("mocked response")}, nil
+		},
+	}
+
+	executer := NewExecuter(mockContainer, &MockKeyService{}, mockGRPCFuncExecuter, zerolog.Nop())
+
+	req := &pb.InvokeRequest{
+		Payload:     []byte("test-body"),
+		ContentType: "text/plain",
+		Http:        &pb.HttpRequest{Method: "POST", PathParams: map[string]string{"id": "42"}},
+	}
+	_, err := executer.Execute("test-action", req, ctx)
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if gotReq != req {
+		t.Errorf("Expected invoke request to be forwarded, got %v", gotReq)
+	}
+}
+
+func TestExecuter_Execute_PropagatesDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	mockContainer := &MockContainer{
+		IsRunningFunc: func(key string, ctx context.Context) bool {
+			return true
+		},
+	}
+
+	var gotDeadline time.Time
+	mockGRPCFuncExecuter := &MockGRPCFuncExecuter{
+		invokeFunc: func(ctx context.Context, url string, req *pb.InvokeRequest) (*pb.InvokeResult, error) {
+			gotDeadline, _ = ctx.Deadline()
+			return &pb.InvokeResult{}, nil
+		},
+	}
+
+	executer := NewExecuter(mockContainer, &MockKeyService{}, mockGRPCFuncExecuter, zerolog.Nop())
+
+	if _, err := executer.Execute("test-action", &pb.InvokeRequest{}, ctx); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	want, _ := ctx.Deadline()
+	if !gotDeadline.Equal(want) {
+		t.Errorf("Expected deadline %v, got %v", want, gotDeadline)
+	}
+}
+
+func TestExecuter_ExecuteStream(t *testing.T) {
+	mockContainer := &MockContainer{
+		IsRunningFunc: func(key string, ctx context.Context) bool {
+			return true
+		},
+		GetPortFunc: func(key string, ctx context.Context) int {
+			return 9090
+		},
+	}
+
+	mockGRPCFuncExecuter := &MockGRPCFuncExecuter{
+		invokeStreamFunc: func(ctx context.Context, url string, req *pb.InvokeRequest, send func(*pb.InvokeChunk) error) error {
+			if url != "localhost:9090" {
+				t.Errorf("Expected url localhost:9090, got %s", url)
+			}
+			for _, data := range []string{"one", "two"} {
+				if err := send(&pb.InvokeChunk{Data: []byte(data)}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	executer := NewExecuter(mockContainer, &MockKeyService{}, mockGRPCFuncExecuter, zerolog.Nop())
+
+	var got []string
+	err := executer.ExecuteStream("test-action", &pb.InvokeRequest{}, context.Background(), func(chunk *pb.InvokeChunk) error {
+		got = append(got, string(chunk.GetData()))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(got) != 2 || got[0] != "one" || got[1] != "two" {
+		t.Errorf("Expected chunks [one two], got %v", got)
+	}
+}
+
+func TestExecuter_ExecuteStream_UnknownAction(t *testing.T) {
+	mockKeyService := &MockKeyService{
+		GetKeyFromActionFunc: func(action string) (string, error) {
+			return "", fmt.Errorf("no key: %w", fs.ErrNotExist)
+		},
+	}
+
+	executer := NewExecuter(&MockContainer{}, mockKeyService, &MockGRPCFuncExecuter{}, zerolog.Nop())
+
+	err := executer.ExecuteStream("missing", &pb.InvokeRequest{}, context.Background(), func(*pb.InvokeChunk) error {
+		t.Error("Expected no chunks for an unknown action")
+		return nil
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected code %s, got %s", codes.NotFound, status.Code(err))
+	}
+}
@@ -2,29 +2,83 @@ package funcinvoker
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"time"
+	"io"
 
 	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"github.com/Ow1Dev/NoctiFunc/pkg/communication"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
 type StandardGRPCClient struct {
-	timeout time.Duration
+	maxMessageSize int
 }
 
-func NewStandardGRPCClient(timeout time.Duration) *StandardGRPCClient {
+func NewStandardGRPCClient(maxMessageSize int) *StandardGRPCClient {
 	return &StandardGRPCClient{
-		timeout: timeout,
+		maxMessageSize: maxMessageSize,
 	}
 }
 
-func (c *StandardGRPCClient) Invoke(ctx context.Context, url, payload string) (string, error) {
-	conn, err := grpc.NewClient(url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// Invoke calls the function listening on url. The deadline of ctx becomes
+// the deadline of the function's own context.
+func (c *StandardGRPCClient) Invoke(ctx context.Context, url string, req *pb.InvokeRequest) (*pb.InvokeResult, error) {
+	var r *pb.InvokeResult
+	err := c.call(url, func(client pb.FunctionRunnerServiceClient) error {
+		log.Debug().Str("request_id", communication.RequestID(ctx)).Msgf("Request body: %d bytes of %s", len(req.GetPayload()), req.GetContentType())
+		var err error
+		r, err = client.Invoke(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute command in Docker container: %w", err)
+	}
+
+	return r, nil
+}
+
+// InvokeStream calls the function listening on url and passes each chunk of
+// its output to send as it arrives.
+func (c *StandardGRPCClient) InvokeStream(ctx context.Context, url string, req *pb.InvokeRequest, send func(*pb.InvokeChunk) error) error {
+	err := c.call(url, func(client pb.FunctionRunnerServiceClient) error {
+		log.Debug().Str("request_id", communication.RequestID(ctx)).Msgf("Request body: %d bytes of %s", len(req.GetPayload()), req.GetContentType())
+		stream, err := client.InvokeStream(ctx, req)
+		if err != nil {
+			return err
+		}
+		for {
+			chunk, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := send(chunk); err != nil {
+				return err
+			}
+		}
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to connect to gRPC server: %w", err)
+		return fmt.Errorf("failed to execute command in Docker container: %w", err)
+	}
+	return nil
+}
+
+// call runs fn with a client connected to the function listening on url.
+func (c *StandardGRPCClient) call(url string, fn func(pb.FunctionRunnerServiceClient) error) error {
+	conn, err := grpc.NewClient(url,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallSendMsgSize(c.maxMessageSize),
+			grpc.MaxCallRecvMsgSize(c.maxMessageSize),
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to connect to gRPC server: %w", err)
 	}
 
 	defer func() {
@@ -33,19 +87,5 @@ func (c *StandardGRPCClient) Invoke(ctx context.Context, url, payload string) (s
 		}
 	}()
 
-	client := pb.NewFunctionRunnerServiceClient(conn)
-
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
-	defer cancel()
-
-	log.Debug().Msgf("Request body: %s", payload)
-	r, err := client.Invoke(ctx, &pb.InvokeRequest{
-		Payload: payload,
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to execute command in Docker container: %w", err)
-	}
-
-	// Implement the logic to execute the command in the Docker container
-	return r.Output, nil
+	return fn(pb.NewFunctionRunnerServiceClient(conn))
 }
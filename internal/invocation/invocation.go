@@ -0,0 +1,165 @@
+package invocation
+
+import (
+	"context"
+	"crypto/rand"
+	"sync"
+	"time"
+
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	serverpb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// RunFunc executes an invocation and returns its response.
+type RunFunc func(ctx context.Context) (*pb.ExecuteResponse, error)
+
+// Store runs invocations in the background and keeps their outcome until
+// retention has passed since they finished. At most limit invocations are
+// running or kept at a time.
+type Store struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	timeout   time.Duration
+	retention time.Duration
+	limit     int
+	now       func() time.Time
+
+	mu          sync.Mutex
+	invocations map[string]*pb.AsyncInvocation
+	wg          sync.WaitGroup
+}
+
+// NewStore returns a store whose invocations run under ctx, each bounded by
+// the timeout of its request or, when the request sets none, by timeout.
+func NewStore(ctx context.Context, timeout, retention time.Duration, limit int) *Store {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Store{
+		ctx:         ctx,
+		cancel:      cancel,
+		timeout:     timeout,
+		retention:   retention,
+		limit:       limit,
+		now:         time.Now,
+		invocations: make(map[string]*pb.AsyncInvocation),
+	}
+}
+
+// Start runs run for req in the background and returns the invocation in its
+// running state. The invocation records the route and caller of req, without
+// the caller's claims. Start fails with ResourceExhausted when the store is full.
+func (s *Store) Start(req *pb.ExecuteRequest, run RunFunc) (*pb.AsyncInvocation, error) {
+	inv := &pb.AsyncInvocation{
+		Id:        rand.Text(),
+		Action:    req.GetAction(),
+		State:     pb.AsyncInvocation_RUNNING,
+		CreatedAt: s.now().UnixMilli(),
+		Route:     req.GetRoute(),
+	}
+	if identity := req.GetHttp().GetIdentity(); identity != nil {
+		inv.Caller = &serverpb.Identity{
+			Scheme:   identity.GetScheme(),
+			ApiKeyId: identity.GetApiKeyId(),
+			Subject:  identity.GetSubject(),
+		}
+	}
+
+	s.mu.Lock()
+	s.sweep()
+	if len(s.invocations) >= s.limit {
+		s.mu.Unlock()
+		return nil, status.Errorf(codes.ResourceExhausted, "too many invocations: %d are running or kept", s.limit)
+	}
+	s.invocations[inv.Id] = inv
+	started := proto.Clone(inv).(*pb.AsyncInvocation)
+	s.mu.Unlock()
+
+	timeout := s.timeout
+	if ms := req.GetTimeoutMs(); ms > 0 {
+		timeout = time.Duration(ms) * time.Millisecond
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ctx, cancel := context.WithTimeout(s.ctx, timeout)
+		defer cancel()
+		resp, err := run(ctx)
+		s.finish(inv.Id, resp, err)
+	}()
+
+	return started, nil
+}
+
+// Get returns the invocation with id, or false if it is unknown or its retention expired.
+func (s *Store) Get(id string) (*pb.AsyncInvocation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	inv, ok := s.invocations[id]
+	if !ok {
+		return nil, false
+	}
+	return proto.Clone(inv).(*pb.AsyncInvocation), true
+}
+
+// Wait blocks until all running invocations have finished.
+func (s *Store) Wait() {
+	s.wg.Wait()
+}
+
+// Shutdown waits for the running invocations to finish. Those still running
+// when ctx is done are canceled, and Shutdown returns once they have stopped.
+func (s *Store) Shutdown(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+	}
+}
+
+func (s *Store) finish(id string, resp *pb.ExecuteResponse, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inv, ok := s.invocations[id]
+	if !ok {
+		return
+	}
+	inv.CompletedAt = s.now().UnixMilli()
+	if err != nil {
+		st := status.Convert(err)
+		inv.State = pb.AsyncInvocation_FAILED
+		inv.ErrorCode = int32(st.Code())
+		inv.ErrorMessage = st.Message()
+		for _, detail := range st.Details() {
+			if d, ok := detail.(*serverpb.ErrorDetail); ok {
+				inv.ErrorDetail = d
+			}
+		}
+		return
+	}
+	inv.State = pb.AsyncInvocation_SUCCEEDED
+	inv.Result = resp
+}
+
+// sweep drops finished invocations whose retention expired. s.mu must be held.
+func (s *Store) sweep() {
+	cutoff := s.now().Add(-s.retention).UnixMilli()
+	for id, inv := range s.invocations {
+		if inv.GetCompletedAt() != 0 && inv.GetCompletedAt() <= cutoff {
+			delete(s.invocations, id)
+		}
+	}
+}
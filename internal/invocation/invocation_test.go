@@ -0,0 +1,205 @@
+package invocation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	serverpb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func start(t *testing.T, store *Store, action string, run RunFunc) *pb.AsyncInvocation {
+	t.Helper()
+	inv, err := store.Start(&pb.ExecuteRequest{Action: action}, run)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return inv
+}
+
+func TestStore_Succeeded(t *testing.T) {
+	store := NewStore(context.Background(), time.Minute, time.Hour, 10)
+	release := make(chan struct{})
+
+	inv := start(t, store, "orders", func(ctx context.Context) (*pb.ExecuteResponse, error) {
+		<-release
+		return &pb.ExecuteResponse{Resp: []byte("done")}, nil
+	})
+	if inv.GetState() != pb.AsyncInvocation_RUNNING {
+		t.Fatalf("expected running invocation, got %v", inv.GetState())
+	}
+
+	got, ok := store.Get(inv.GetId())
+	if !ok || got.GetState() != pb.AsyncInvocation_RUNNING {
+		t.Fatalf("expected running invocation, got %v", got)
+	}
+
+	close(release)
+	store.Wait()
+
+	got, ok = store.Get(inv.GetId())
+	if !ok {
+		t.Fatal("expected invocation to be kept")
+	}
+	if got.GetState() != pb.AsyncInvocation_SUCCEEDED {
+		t.Errorf("expected succeeded invocation, got %v", got.GetState())
+	}
+	if string(got.GetResult().GetResp()) != "done" {
+		t.Errorf("expected result 'done', got '%s'", got.GetResult().GetResp())
+	}
+	if got.GetCompletedAt() == 0 {
+		t.Error("expected completion time to be set")
+	}
+}
+
+func TestStore_Failed(t *testing.T) {
+	store := NewStore(context.Background(), time.Minute, time.Hour, 10)
+
+	inv := start(t, store, "orders", func(ctx context.Context) (*pb.ExecuteResponse, error) {
+		st, _ := status.New(codes.InvalidArgument, "bad order").WithDetails(&serverpb.ErrorDetail{HttpStatus: 422, Code: "bad_order"})
+		return nil, st.Err()
+	})
+	store.Wait()
+
+	got, _ := store.Get(inv.GetId())
+	if got.GetState() != pb.AsyncInvocation_FAILED {
+		t.Fatalf("expected failed invocation, got %v", got.GetState())
+	}
+	if codes.Code(got.GetErrorCode()) != codes.InvalidArgument || got.GetErrorMessage() != "bad order" {
+		t.Errorf("expected InvalidArgument 'bad order', got %v '%s'", codes.Code(got.GetErrorCode()), got.GetErrorMessage())
+	}
+	if got.GetErrorDetail().GetCode() != "bad_order" {
+		t.Errorf("expected error detail 'bad_order', got '%s'", got.GetErrorDetail().GetCode())
+	}
+}
+
+func TestStore_Timeout(t *testing.T) {
+	store := NewStore(context.Background(), time.Millisecond, time.Hour, 10)
+
+	inv := start(t, store, "slow", func(ctx context.Context) (*pb.ExecuteResponse, error) {
+		<-ctx.Done()
+		return nil, status.Error(codes.DeadlineExceeded, "timed out")
+	})
+	store.Wait()
+
+	got, _ := store.Get(inv.GetId())
+	if codes.Code(got.GetErrorCode()) != codes.DeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded, got %v", codes.Code(got.GetErrorCode()))
+	}
+}
+
+func TestStore_Retention(t *testing.T) {
+	store := NewStore(context.Background(), time.Minute, time.Hour, 10)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	inv := start(t, store, "orders", func(ctx context.Context) (*pb.ExecuteResponse, error) {
+		return &pb.ExecuteResponse{}, nil
+	})
+	store.Wait()
+
+	now = now.Add(59 * time.Minute)
+	if _, ok := store.Get(inv.GetId()); !ok {
+		t.Fatal("expected invocation to be kept within retention")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := store.Get(inv.GetId()); ok {
+		t.Error("expected invocation to be dropped after retention")
+	}
+}
+
+func TestStore_Limit(t *testing.T) {
+	store := NewStore(context.Background(), time.Minute, time.Hour, 1)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	start(t, store, "orders", func(ctx context.Context) (*pb.ExecuteResponse, error) {
+		return &pb.ExecuteResponse{}, nil
+	})
+	store.Wait()
+
+	run := func(ctx context.Context) (*pb.ExecuteResponse, error) { return &pb.ExecuteResponse{}, nil }
+	if _, err := store.Start(&pb.ExecuteRequest{Action: "orders"}, run); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+
+	// Room is made once the kept invocation expires
+	now = now.Add(time.Hour)
+	start(t, store, "orders", run)
+	store.Wait()
+}
+
+func TestStore_Caller(t *testing.T) {
+	store := NewStore(context.Background(), time.Minute, time.Hour, 10)
+
+	inv, err := store.Start(&pb.ExecuteRequest{
+		Action: "orders",
+		Route:  "/orders",
+		Http: &serverpb.HttpRequest{
+			Identity: &serverpb.Identity{Scheme: "jwt", Subject: "user-42", Claims: []byte(`{"sub":"user-42"}`)},
+		},
+	}, func(ctx context.Context) (*pb.ExecuteResponse, error) {
+		return &pb.ExecuteResponse{}, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store.Wait()
+
+	got, _ := store.Get(inv.GetId())
+	if got.GetRoute() != "/orders" || got.GetCaller().GetSubject() != "user-42" {
+		t.Errorf("expected route and caller to be kept, got %v", got)
+	}
+	if len(got.GetCaller().GetClaims()) != 0 {
+		t.Error("expected claims not to be kept")
+	}
+}
+
+func TestStore_RequestTimeout(t *testing.T) {
+	store := NewStore(context.Background(), time.Hour, time.Hour, 10)
+
+	var remaining time.Duration
+	_, err := store.Start(&pb.ExecuteRequest{Action: "slow", TimeoutMs: 50}, func(ctx context.Context) (*pb.ExecuteResponse, error) {
+		deadline, _ := ctx.Deadline()
+		remaining = time.Until(deadline)
+		return &pb.ExecuteResponse{}, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store.Wait()
+
+	if remaining <= 0 || remaining > 50*time.Millisecond {
+		t.Errorf("expected the request's 50ms timeout, got %v left", remaining)
+	}
+}
+
+func TestStore_Shutdown(t *testing.T) {
+	store := NewStore(context.Background(), time.Hour, time.Hour, 10)
+	release := make(chan struct{})
+
+	finished := start(t, store, "quick", func(ctx context.Context) (*pb.ExecuteResponse, error) {
+		<-release
+		return &pb.ExecuteResponse{}, nil
+	})
+	stuck := start(t, store, "stuck", func(ctx context.Context) (*pb.ExecuteResponse, error) {
+		<-ctx.Done()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	close(release)
+	store.Shutdown(ctx)
+
+	if got, _ := store.Get(finished.GetId()); got.GetState() != pb.AsyncInvocation_SUCCEEDED {
+		t.Errorf("expected the quick invocation to finish, got %v", got.GetState())
+	}
+	if got, _ := store.Get(stuck.GetId()); codes.Code(got.GetErrorCode()) != codes.Canceled {
+		t.Errorf("expected the stuck invocation to be canceled, got %v", codes.Code(got.GetErrorCode()))
+	}
+}
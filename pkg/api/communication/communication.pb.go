@@ -11,6 +11,7 @@ import (
 	sync "sync"
 	unsafe "unsafe"
 
+	server "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 )
@@ -22,10 +23,71 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type AsyncInvocation_State int32
+
+const (
+	AsyncInvocation_STATE_UNSPECIFIED AsyncInvocation_State = 0
+	AsyncInvocation_RUNNING           AsyncInvocation_State = 1
+	AsyncInvocation_SUCCEEDED         AsyncInvocation_State = 2
+	AsyncInvocation_FAILED            AsyncInvocation_State = 3
+)
+
+// Enum value maps for AsyncInvocation_State.
+var (
+	AsyncInvocation_State_name = map[int32]string{
+		0: "STATE_UNSPECIFIED",
+		1: "RUNNING",
+		2: "SUCCEEDED",
+		3: "FAILED",
+	}
+	AsyncInvocation_State_value = map[string]int32{
+		"STATE_UNSPECIFIED": 0,
+		"RUNNING":           1,
+		"SUCCEEDED":         2,
+		"FAILED":            3,
+	}
+)
+
+func (x AsyncInvocation_State) Enum() *AsyncInvocation_State {
+	p := new(AsyncInvocation_State)
+	*p = x
+	return p
+}
+
+func (x AsyncInvocation_State) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AsyncInvocation_State) Descriptor() protoreflect.EnumDescriptor {
+	return file_communication_communication_proto_enumTypes[0].Descriptor()
+}
+
+func (AsyncInvocation_State) Type() protoreflect.EnumType {
+	return &file_communication_communication_proto_enumTypes[0]
+}
+
+func (x AsyncInvocation_State) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AsyncInvocation_State.Descriptor instead.
+func (AsyncInvocation_State) EnumDescriptor() ([]byte, []int) {
+	return file_communication_communication_proto_rawDescGZIP(), []int{4, 0}
+}
+
 type ExecuteRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Action        string                 `protobuf:"bytes,1,opt,name=action,proto3" json:"action,omitempty"`
-	Body          string                 `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Action      string                 `protobuf:"bytes,1,opt,name=action,proto3" json:"action,omitempty"`
+	Body        []byte                 `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
+	Http        *server.HttpRequest    `protobuf:"bytes,5,opt,name=http,proto3" json:"http,omitempty"`
+	ContentType string                 `protobuf:"bytes,6,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	// route identifies the route that started an asynchronous invocation as
+	// "<method> <path template>", so Prism can check who reads its outcome.
+	Route string `protobuf:"bytes,7,opt,name=route,proto3" json:"route,omitempty"`
+	// timeout_ms is the route's timeout. It bounds an asynchronous invocation,
+	// and how long ExecuteStream waits for the function's first output; streams
+	// carry no deadline once output flows. Zero leaves the bound to igniterelay.
+	TimeoutMs     int64 `protobuf:"varint,8,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -67,17 +129,49 @@ func (x *ExecuteRequest) GetAction() string {
 	return ""
 }
 
-func (x *ExecuteRequest) GetBody() string {
+func (x *ExecuteRequest) GetBody() []byte {
 	if x != nil {
 		return x.Body
 	}
+	return nil
+}
+
+func (x *ExecuteRequest) GetHttp() *server.HttpRequest {
+	if x != nil {
+		return x.Http
+	}
+	return nil
+}
+
+func (x *ExecuteRequest) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
 	return ""
 }
 
+func (x *ExecuteRequest) GetRoute() string {
+	if x != nil {
+		return x.Route
+	}
+	return ""
+}
+
+func (x *ExecuteRequest) GetTimeoutMs() int64 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
+// Failed executions are reported as gRPC status errors, with an ErrorDetail
+// attached when the failure maps to a specific HTTP response.
 type ExecuteResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
-	Resp          string                 `protobuf:"bytes,2,opt,name=resp,proto3" json:"resp,omitempty"`
+	Resp          []byte                 `protobuf:"bytes,2,opt,name=resp,proto3" json:"resp,omitempty"`
+	Http          *server.HttpResponse   `protobuf:"bytes,3,opt,name=http,proto3" json:"http,omitempty"`
+	ContentType   string                 `protobuf:"bytes,4,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -119,26 +213,519 @@ func (x *ExecuteResponse) GetStatus() string {
 	return ""
 }
 
-func (x *ExecuteResponse) GetResp() string {
+func (x *ExecuteResponse) GetResp() []byte {
 	if x != nil {
 		return x.Resp
 	}
+	return nil
+}
+
+func (x *ExecuteResponse) GetHttp() *server.HttpResponse {
+	if x != nil {
+		return x.Http
+	}
+	return nil
+}
+
+func (x *ExecuteResponse) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+// ExecuteChunk is one message of a streamed execution. The first chunk
+// carries the HTTP response and content type; every chunk may carry output.
+type ExecuteChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Http          *server.HttpResponse   `protobuf:"bytes,2,opt,name=http,proto3" json:"http,omitempty"`
+	ContentType   string                 `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteChunk) Reset() {
+	*x = ExecuteChunk{}
+	mi := &file_communication_communication_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteChunk) ProtoMessage() {}
+
+func (x *ExecuteChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_communication_communication_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteChunk.ProtoReflect.Descriptor instead.
+func (*ExecuteChunk) Descriptor() ([]byte, []int) {
+	return file_communication_communication_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ExecuteChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ExecuteChunk) GetHttp() *server.HttpResponse {
+	if x != nil {
+		return x.Http
+	}
+	return nil
+}
+
+func (x *ExecuteChunk) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+type GetInvocationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetInvocationRequest) Reset() {
+	*x = GetInvocationRequest{}
+	mi := &file_communication_communication_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInvocationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInvocationRequest) ProtoMessage() {}
+
+func (x *GetInvocationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_communication_communication_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInvocationRequest.ProtoReflect.Descriptor instead.
+func (*GetInvocationRequest) Descriptor() ([]byte, []int) {
+	return file_communication_communication_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetInvocationRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// AsyncInvocation is an execution running in the background. Finished
+// invocations are kept until their retention expires.
+type AsyncInvocation struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Action      string                 `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`
+	State       AsyncInvocation_State  `protobuf:"varint,3,opt,name=state,proto3,enum=AsyncInvocation_State" json:"state,omitempty"`
+	CreatedAt   int64                  `protobuf:"varint,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`       // Unix milliseconds
+	CompletedAt int64                  `protobuf:"varint,5,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"` // Unix milliseconds, 0 while running
+	// Set when the invocation succeeded.
+	Result *ExecuteResponse `protobuf:"bytes,6,opt,name=result,proto3" json:"result,omitempty"`
+	// Set when the invocation failed: the gRPC status the synchronous Execute
+	// would have returned.
+	ErrorCode    int32               `protobuf:"varint,7,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	ErrorMessage string              `protobuf:"bytes,8,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	ErrorDetail  *server.ErrorDetail `protobuf:"bytes,9,opt,name=error_detail,json=errorDetail,proto3" json:"error_detail,omitempty"`
+	// The route that started the invocation and the caller Prism authenticated
+	// for it, without claims. Only that caller may read the invocation.
+	Route         string           `protobuf:"bytes,10,opt,name=route,proto3" json:"route,omitempty"`
+	Caller        *server.Identity `protobuf:"bytes,11,opt,name=caller,proto3" json:"caller,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AsyncInvocation) Reset() {
+	*x = AsyncInvocation{}
+	mi := &file_communication_communication_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AsyncInvocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AsyncInvocation) ProtoMessage() {}
+
+func (x *AsyncInvocation) ProtoReflect() protoreflect.Message {
+	mi := &file_communication_communication_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AsyncInvocation.ProtoReflect.Descriptor instead.
+func (*AsyncInvocation) Descriptor() ([]byte, []int) {
+	return file_communication_communication_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AsyncInvocation) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AsyncInvocation) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
 	return ""
 }
 
+func (x *AsyncInvocation) GetState() AsyncInvocation_State {
+	if x != nil {
+		return x.State
+	}
+	return AsyncInvocation_STATE_UNSPECIFIED
+}
+
+func (x *AsyncInvocation) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *AsyncInvocation) GetCompletedAt() int64 {
+	if x != nil {
+		return x.CompletedAt
+	}
+	return 0
+}
+
+func (x *AsyncInvocation) GetResult() *ExecuteResponse {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *AsyncInvocation) GetErrorCode() int32 {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return 0
+}
+
+func (x *AsyncInvocation) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *AsyncInvocation) GetErrorDetail() *server.ErrorDetail {
+	if x != nil {
+		return x.ErrorDetail
+	}
+	return nil
+}
+
+func (x *AsyncInvocation) GetRoute() string {
+	if x != nil {
+		return x.Route
+	}
+	return ""
+}
+
+func (x *AsyncInvocation) GetCaller() *server.Identity {
+	if x != nil {
+		return x.Caller
+	}
+	return nil
+}
+
+type PostToConnectionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ConnectionId  string                 `protobuf:"bytes,1,opt,name=connection_id,json=connectionId,proto3" json:"connection_id,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Binary        bool                   `protobuf:"varint,3,opt,name=binary,proto3" json:"binary,omitempty"` // send a binary frame instead of a text frame
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PostToConnectionRequest) Reset() {
+	*x = PostToConnectionRequest{}
+	mi := &file_communication_communication_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PostToConnectionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostToConnectionRequest) ProtoMessage() {}
+
+func (x *PostToConnectionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_communication_communication_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PostToConnectionRequest.ProtoReflect.Descriptor instead.
+func (*PostToConnectionRequest) Descriptor() ([]byte, []int) {
+	return file_communication_communication_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PostToConnectionRequest) GetConnectionId() string {
+	if x != nil {
+		return x.ConnectionId
+	}
+	return ""
+}
+
+func (x *PostToConnectionRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *PostToConnectionRequest) GetBinary() bool {
+	if x != nil {
+		return x.Binary
+	}
+	return false
+}
+
+type PostToConnectionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PostToConnectionResponse) Reset() {
+	*x = PostToConnectionResponse{}
+	mi := &file_communication_communication_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PostToConnectionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostToConnectionResponse) ProtoMessage() {}
+
+func (x *PostToConnectionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_communication_communication_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PostToConnectionResponse.ProtoReflect.Descriptor instead.
+func (*PostToConnectionResponse) Descriptor() ([]byte, []int) {
+	return file_communication_communication_proto_rawDescGZIP(), []int{6}
+}
+
+type CloseConnectionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ConnectionId  string                 `protobuf:"bytes,1,opt,name=connection_id,json=connectionId,proto3" json:"connection_id,omitempty"`
+	Code          int32                  `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"` // WebSocket close code, 0 for 1000 (normal closure)
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloseConnectionRequest) Reset() {
+	*x = CloseConnectionRequest{}
+	mi := &file_communication_communication_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloseConnectionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseConnectionRequest) ProtoMessage() {}
+
+func (x *CloseConnectionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_communication_communication_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseConnectionRequest.ProtoReflect.Descriptor instead.
+func (*CloseConnectionRequest) Descriptor() ([]byte, []int) {
+	return file_communication_communication_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CloseConnectionRequest) GetConnectionId() string {
+	if x != nil {
+		return x.ConnectionId
+	}
+	return ""
+}
+
+func (x *CloseConnectionRequest) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *CloseConnectionRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type CloseConnectionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloseConnectionResponse) Reset() {
+	*x = CloseConnectionResponse{}
+	mi := &file_communication_communication_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloseConnectionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseConnectionResponse) ProtoMessage() {}
+
+func (x *CloseConnectionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_communication_communication_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseConnectionResponse.ProtoReflect.Descriptor instead.
+func (*CloseConnectionResponse) Descriptor() ([]byte, []int) {
+	return file_communication_communication_proto_rawDescGZIP(), []int{8}
+}
+
 var File_communication_communication_proto protoreflect.FileDescriptor
 
 const file_communication_communication_proto_rawDesc = "" +
 	"\n" +
-	"!communication/communication.proto\"<\n" +
+	"!communication/communication.proto\x1a\x13server/server.proto\"\xd2\x01\n" +
 	"\x0eExecuteRequest\x12\x16\n" +
 	"\x06action\x18\x01 \x01(\tR\x06action\x12\x12\n" +
-	"\x04body\x18\x02 \x01(\tR\x04body\"=\n" +
+	"\x04body\x18\x02 \x01(\fR\x04body\x12 \n" +
+	"\x04http\x18\x05 \x01(\v2\f.HttpRequestR\x04http\x12!\n" +
+	"\fcontent_type\x18\x06 \x01(\tR\vcontentType\x12\x14\n" +
+	"\x05route\x18\a \x01(\tR\x05route\x12\x1d\n" +
+	"\n" +
+	"timeout_ms\x18\b \x01(\x03R\ttimeoutMsJ\x04\b\x03\x10\x04J\x04\b\x04\x10\x05R\x06paramsR\x06method\"\x83\x01\n" +
 	"\x0fExecuteResponse\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\tR\x06status\x12\x12\n" +
-	"\x04resp\x18\x02 \x01(\tR\x04resp2D\n" +
+	"\x04resp\x18\x02 \x01(\fR\x04resp\x12!\n" +
+	"\x04http\x18\x03 \x01(\v2\r.HttpResponseR\x04http\x12!\n" +
+	"\fcontent_type\x18\x04 \x01(\tR\vcontentType\"h\n" +
+	"\fExecuteChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12!\n" +
+	"\x04http\x18\x02 \x01(\v2\r.HttpResponseR\x04http\x12!\n" +
+	"\fcontent_type\x18\x03 \x01(\tR\vcontentType\"&\n" +
+	"\x14GetInvocationRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xc9\x03\n" +
+	"\x0fAsyncInvocation\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06action\x18\x02 \x01(\tR\x06action\x12,\n" +
+	"\x05state\x18\x03 \x01(\x0e2\x16.AsyncInvocation.StateR\x05state\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\x03R\tcreatedAt\x12!\n" +
+	"\fcompleted_at\x18\x05 \x01(\x03R\vcompletedAt\x12(\n" +
+	"\x06result\x18\x06 \x01(\v2\x10.ExecuteResponseR\x06result\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\a \x01(\x05R\terrorCode\x12#\n" +
+	"\rerror_message\x18\b \x01(\tR\ferrorMessage\x12/\n" +
+	"\ferror_detail\x18\t \x01(\v2\f.ErrorDetailR\verrorDetail\x12\x14\n" +
+	"\x05route\x18\n" +
+	" \x01(\tR\x05route\x12!\n" +
+	"\x06caller\x18\v \x01(\v2\t.IdentityR\x06caller\"F\n" +
+	"\x05State\x12\x15\n" +
+	"\x11STATE_UNSPECIFIED\x10\x00\x12\v\n" +
+	"\aRUNNING\x10\x01\x12\r\n" +
+	"\tSUCCEEDED\x10\x02\x12\n" +
+	"\n" +
+	"\x06FAILED\x10\x03\"j\n" +
+	"\x17PostToConnectionRequest\x12#\n" +
+	"\rconnection_id\x18\x01 \x01(\tR\fconnectionId\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\x12\x16\n" +
+	"\x06binary\x18\x03 \x01(\bR\x06binary\"\x1a\n" +
+	"\x18PostToConnectionResponse\"i\n" +
+	"\x16CloseConnectionRequest\x12#\n" +
+	"\rconnection_id\x18\x01 \x01(\tR\fconnectionId\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\x05R\x04code\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"\x19\n" +
+	"\x17CloseConnectionResponse2\xe4\x01\n" +
 	"\x14CommunicationService\x12,\n" +
-	"\aExecute\x12\x0f.ExecuteRequest\x1a\x10.ExecuteResponseB3Z1github.com/Ow1Dev/noctifunc/pkg/api/communicationb\x06proto3"
+	"\aExecute\x12\x0f.ExecuteRequest\x1a\x10.ExecuteResponse\x121\n" +
+	"\rExecuteStream\x12\x0f.ExecuteRequest\x1a\r.ExecuteChunk0\x01\x121\n" +
+	"\fExecuteAsync\x12\x0f.ExecuteRequest\x1a\x10.AsyncInvocation\x128\n" +
+	"\rGetInvocation\x12\x15.GetInvocationRequest\x1a\x10.AsyncInvocation2\xa2\x01\n" +
+	"\x11ConnectionService\x12G\n" +
+	"\x10PostToConnection\x12\x18.PostToConnectionRequest\x1a\x19.PostToConnectionResponse\x12D\n" +
+	"\x0fCloseConnection\x12\x17.CloseConnectionRequest\x1a\x18.CloseConnectionResponseB3Z1github.com/Ow1Dev/NoctiFunc/pkg/api/communicationb\x06proto3"
 
 var (
 	file_communication_communication_proto_rawDescOnce sync.Once
@@ -152,19 +739,49 @@ func file_communication_communication_proto_rawDescGZIP() []byte {
 	return file_communication_communication_proto_rawDescData
 }
 
-var file_communication_communication_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_communication_communication_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_communication_communication_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_communication_communication_proto_goTypes = []any{
-	(*ExecuteRequest)(nil),  // 0: ExecuteRequest
-	(*ExecuteResponse)(nil), // 1: ExecuteResponse
+	(AsyncInvocation_State)(0),       // 0: AsyncInvocation.State
+	(*ExecuteRequest)(nil),           // 1: ExecuteRequest
+	(*ExecuteResponse)(nil),          // 2: ExecuteResponse
+	(*ExecuteChunk)(nil),             // 3: ExecuteChunk
+	(*GetInvocationRequest)(nil),     // 4: GetInvocationRequest
+	(*AsyncInvocation)(nil),          // 5: AsyncInvocation
+	(*PostToConnectionRequest)(nil),  // 6: PostToConnectionRequest
+	(*PostToConnectionResponse)(nil), // 7: PostToConnectionResponse
+	(*CloseConnectionRequest)(nil),   // 8: CloseConnectionRequest
+	(*CloseConnectionResponse)(nil),  // 9: CloseConnectionResponse
+	(*server.HttpRequest)(nil),       // 10: HttpRequest
+	(*server.HttpResponse)(nil),      // 11: HttpResponse
+	(*server.ErrorDetail)(nil),       // 12: ErrorDetail
+	(*server.Identity)(nil),          // 13: Identity
 }
 var file_communication_communication_proto_depIdxs = []int32{
-	0, // 0: CommunicationService.Execute:input_type -> ExecuteRequest
-	1, // 1: CommunicationService.Execute:output_type -> ExecuteResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	10, // 0: ExecuteRequest.http:type_name -> HttpRequest
+	11, // 1: ExecuteResponse.http:type_name -> HttpResponse
+	11, // 2: ExecuteChunk.http:type_name -> HttpResponse
+	0,  // 3: AsyncInvocation.state:type_name -> AsyncInvocation.State
+	2,  // 4: AsyncInvocation.result:type_name -> ExecuteResponse
+	12, // 5: AsyncInvocation.error_detail:type_name -> ErrorDetail
+	13, // 6: AsyncInvocation.caller:type_name -> Identity
+	1,  // 7: CommunicationService.Execute:input_type -> ExecuteRequest
+	1,  // 8: CommunicationService.ExecuteStream:input_type -> ExecuteRequest
+	1,  // 9: CommunicationService.ExecuteAsync:input_type -> ExecuteRequest
+	4,  // 10: CommunicationService.GetInvocation:input_type -> GetInvocationRequest
+	6,  // 11: ConnectionService.PostToConnection:input_type -> PostToConnectionRequest
+	8,  // 12: ConnectionService.CloseConnection:input_type -> CloseConnectionRequest
+	2,  // 13: CommunicationService.Execute:output_type -> ExecuteResponse
+	3,  // 14: CommunicationService.ExecuteStream:output_type -> ExecuteChunk
+	5,  // 15: CommunicationService.ExecuteAsync:output_type -> AsyncInvocation
+	5,  // 16: CommunicationService.GetInvocation:output_type -> AsyncInvocation
+	7,  // 17: ConnectionService.PostToConnection:output_type -> PostToConnectionResponse
+	9,  // 18: ConnectionService.CloseConnection:output_type -> CloseConnectionResponse
+	13, // [13:19] is the sub-list for method output_type
+	7,  // [7:13] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_communication_communication_proto_init() }
@@ -177,13 +794,14 @@ func file_communication_communication_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_communication_communication_proto_rawDesc), len(file_communication_communication_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   2,
+			NumEnums:      1,
+			NumMessages:   9,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_communication_communication_proto_goTypes,
 		DependencyIndexes: file_communication_communication_proto_depIdxs,
+		EnumInfos:         file_communication_communication_proto_enumTypes,
 		MessageInfos:      file_communication_communication_proto_msgTypes,
 	}.Build()
 	File_communication_communication_proto = out.File
@@ -22,16 +22,360 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// HttpRequest describes the HTTP request that triggered an invocation.
+// The request body travels separately as the invocation payload.
+type HttpRequest struct {
+	state      protoimpl.MessageState   `protogen:"open.v1"`
+	Method     string                   `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
+	Path       string                   `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	RawQuery   string                   `protobuf:"bytes,3,opt,name=raw_query,json=rawQuery,proto3" json:"raw_query,omitempty"`
+	Headers    map[string]*HeaderValues `protobuf:"bytes,4,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	RemoteAddr string                   `protobuf:"bytes,5,opt,name=remote_addr,json=remoteAddr,proto3" json:"remote_addr,omitempty"`
+	Host       string                   `protobuf:"bytes,6,opt,name=host,proto3" json:"host,omitempty"`
+	PathParams map[string]string        `protobuf:"bytes,7,rep,name=path_params,json=pathParams,proto3" json:"path_params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Identity   *Identity                `protobuf:"bytes,8,opt,name=identity,proto3" json:"identity,omitempty"`
+	// websocket is set when the invocation handles an event of a websocket route.
+	Websocket     *WebSocketEvent `protobuf:"bytes,9,opt,name=websocket,proto3" json:"websocket,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HttpRequest) Reset() {
+	*x = HttpRequest{}
+	mi := &file_server_server_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HttpRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HttpRequest) ProtoMessage() {}
+
+func (x *HttpRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_server_server_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HttpRequest.ProtoReflect.Descriptor instead.
+func (*HttpRequest) Descriptor() ([]byte, []int) {
+	return file_server_server_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *HttpRequest) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *HttpRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *HttpRequest) GetRawQuery() string {
+	if x != nil {
+		return x.RawQuery
+	}
+	return ""
+}
+
+func (x *HttpRequest) GetHeaders() map[string]*HeaderValues {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *HttpRequest) GetRemoteAddr() string {
+	if x != nil {
+		return x.RemoteAddr
+	}
+	return ""
+}
+
+func (x *HttpRequest) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *HttpRequest) GetPathParams() map[string]string {
+	if x != nil {
+		return x.PathParams
+	}
+	return nil
+}
+
+func (x *HttpRequest) GetIdentity() *Identity {
+	if x != nil {
+		return x.Identity
+	}
+	return nil
+}
+
+func (x *HttpRequest) GetWebsocket() *WebSocketEvent {
+	if x != nil {
+		return x.Websocket
+	}
+	return nil
+}
+
+// WebSocketEvent describes the event on a WebSocket connection held by Prism.
+// The message itself travels as the invocation payload.
+type WebSocketEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ConnectionId  string                 `protobuf:"bytes,1,opt,name=connection_id,json=connectionId,proto3" json:"connection_id,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`      // "connect", "message" or "disconnect"
+	Binary        bool                   `protobuf:"varint,3,opt,name=binary,proto3" json:"binary,omitempty"` // set when a message arrived in a binary frame
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WebSocketEvent) Reset() {
+	*x = WebSocketEvent{}
+	mi := &file_server_server_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebSocketEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebSocketEvent) ProtoMessage() {}
+
+func (x *WebSocketEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_server_server_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebSocketEvent.ProtoReflect.Descriptor instead.
+func (*WebSocketEvent) Descriptor() ([]byte, []int) {
+	return file_server_server_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *WebSocketEvent) GetConnectionId() string {
+	if x != nil {
+		return x.ConnectionId
+	}
+	return ""
+}
+
+func (x *WebSocketEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *WebSocketEvent) GetBinary() bool {
+	if x != nil {
+		return x.Binary
+	}
+	return false
+}
+
+// Identity describes the caller Prism authenticated for the route. It is
+// unset on public routes.
+type Identity struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Scheme   string                 `protobuf:"bytes,1,opt,name=scheme,proto3" json:"scheme,omitempty"`
+	ApiKeyId string                 `protobuf:"bytes,2,opt,name=api_key_id,json=apiKeyId,proto3" json:"api_key_id,omitempty"`
+	Subject  string                 `protobuf:"bytes,3,opt,name=subject,proto3" json:"subject,omitempty"`
+	// claims holds the validated JWT claims as a JSON object.
+	Claims        []byte `protobuf:"bytes,4,opt,name=claims,proto3" json:"claims,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Identity) Reset() {
+	*x = Identity{}
+	mi := &file_server_server_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Identity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Identity) ProtoMessage() {}
+
+func (x *Identity) ProtoReflect() protoreflect.Message {
+	mi := &file_server_server_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Identity.ProtoReflect.Descriptor instead.
+func (*Identity) Descriptor() ([]byte, []int) {
+	return file_server_server_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Identity) GetScheme() string {
+	if x != nil {
+		return x.Scheme
+	}
+	return ""
+}
+
+func (x *Identity) GetApiKeyId() string {
+	if x != nil {
+		return x.ApiKeyId
+	}
+	return ""
+}
+
+func (x *Identity) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *Identity) GetClaims() []byte {
+	if x != nil {
+		return x.Claims
+	}
+	return nil
+}
+
+// HttpResponse lets a function control the HTTP response. The body travels
+// separately as the invocation output.
+type HttpResponse struct {
+	state         protoimpl.MessageState   `protogen:"open.v1"`
+	StatusCode    int32                    `protobuf:"varint,1,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
+	Headers       map[string]*HeaderValues `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HttpResponse) Reset() {
+	*x = HttpResponse{}
+	mi := &file_server_server_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HttpResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HttpResponse) ProtoMessage() {}
+
+func (x *HttpResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_server_server_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HttpResponse.ProtoReflect.Descriptor instead.
+func (*HttpResponse) Descriptor() ([]byte, []int) {
+	return file_server_server_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *HttpResponse) GetStatusCode() int32 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *HttpResponse) GetHeaders() map[string]*HeaderValues {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+type HeaderValues struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []string               `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeaderValues) Reset() {
+	*x = HeaderValues{}
+	mi := &file_server_server_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeaderValues) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeaderValues) ProtoMessage() {}
+
+func (x *HeaderValues) ProtoReflect() protoreflect.Message {
+	mi := &file_server_server_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeaderValues.ProtoReflect.Descriptor instead.
+func (*HeaderValues) Descriptor() ([]byte, []int) {
+	return file_server_server_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *HeaderValues) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
 type InvokeRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Payload       string                 `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"`
+	Payload       []byte                 `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"`
+	Http          *HttpRequest           `protobuf:"bytes,4,opt,name=http,proto3" json:"http,omitempty"`
+	ContentType   string                 `protobuf:"bytes,5,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *InvokeRequest) Reset() {
 	*x = InvokeRequest{}
-	mi := &file_server_server_proto_msgTypes[0]
+	mi := &file_server_server_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -43,7 +387,7 @@ func (x *InvokeRequest) String() string {
 func (*InvokeRequest) ProtoMessage() {}
 
 func (x *InvokeRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_server_server_proto_msgTypes[0]
+	mi := &file_server_server_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -56,26 +400,42 @@ func (x *InvokeRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InvokeRequest.ProtoReflect.Descriptor instead.
 func (*InvokeRequest) Descriptor() ([]byte, []int) {
-	return file_server_server_proto_rawDescGZIP(), []int{0}
+	return file_server_server_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *InvokeRequest) GetPayload() string {
+func (x *InvokeRequest) GetPayload() []byte {
 	if x != nil {
 		return x.Payload
 	}
+	return nil
+}
+
+func (x *InvokeRequest) GetHttp() *HttpRequest {
+	if x != nil {
+		return x.Http
+	}
+	return nil
+}
+
+func (x *InvokeRequest) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
 	return ""
 }
 
 type InvokeResult struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Output        string                 `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"`
+	Output        []byte                 `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"`
+	Http          *HttpResponse          `protobuf:"bytes,2,opt,name=http,proto3" json:"http,omitempty"`
+	ContentType   string                 `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *InvokeResult) Reset() {
 	*x = InvokeResult{}
-	mi := &file_server_server_proto_msgTypes[1]
+	mi := &file_server_server_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -87,7 +447,7 @@ func (x *InvokeResult) String() string {
 func (*InvokeResult) ProtoMessage() {}
 
 func (x *InvokeResult) ProtoReflect() protoreflect.Message {
-	mi := &file_server_server_proto_msgTypes[1]
+	mi := &file_server_server_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -100,13 +460,151 @@ func (x *InvokeResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InvokeResult.ProtoReflect.Descriptor instead.
 func (*InvokeResult) Descriptor() ([]byte, []int) {
-	return file_server_server_proto_rawDescGZIP(), []int{1}
+	return file_server_server_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *InvokeResult) GetOutput() string {
+func (x *InvokeResult) GetOutput() []byte {
 	if x != nil {
 		return x.Output
 	}
+	return nil
+}
+
+func (x *InvokeResult) GetHttp() *HttpResponse {
+	if x != nil {
+		return x.Http
+	}
+	return nil
+}
+
+func (x *InvokeResult) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+// ErrorDetail is attached to the gRPC status of a failed invocation so the
+// gateway can answer with the HTTP status, code and message it describes.
+type ErrorDetail struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HttpStatus    int32                  `protobuf:"varint,1,opt,name=http_status,json=httpStatus,proto3" json:"http_status,omitempty"`
+	Code          string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ErrorDetail) Reset() {
+	*x = ErrorDetail{}
+	mi := &file_server_server_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ErrorDetail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrorDetail) ProtoMessage() {}
+
+func (x *ErrorDetail) ProtoReflect() protoreflect.Message {
+	mi := &file_server_server_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrorDetail.ProtoReflect.Descriptor instead.
+func (*ErrorDetail) Descriptor() ([]byte, []int) {
+	return file_server_server_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ErrorDetail) GetHttpStatus() int32 {
+	if x != nil {
+		return x.HttpStatus
+	}
+	return 0
+}
+
+func (x *ErrorDetail) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *ErrorDetail) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// InvokeChunk is one message of a streamed invocation. The first chunk
+// carries the HTTP response and content type; every chunk may carry output.
+type InvokeChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Http          *HttpResponse          `protobuf:"bytes,2,opt,name=http,proto3" json:"http,omitempty"`
+	ContentType   string                 `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InvokeChunk) Reset() {
+	*x = InvokeChunk{}
+	mi := &file_server_server_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InvokeChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InvokeChunk) ProtoMessage() {}
+
+func (x *InvokeChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_server_server_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InvokeChunk.ProtoReflect.Descriptor instead.
+func (*InvokeChunk) Descriptor() ([]byte, []int) {
+	return file_server_server_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *InvokeChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *InvokeChunk) GetHttp() *HttpResponse {
+	if x != nil {
+		return x.Http
+	}
+	return nil
+}
+
+func (x *InvokeChunk) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
 	return ""
 }
 
@@ -114,13 +612,64 @@ var File_server_server_proto protoreflect.FileDescriptor
 
 const file_server_server_proto_rawDesc = "" +
 	"\n" +
-	"\x13server/server.proto\")\n" +
+	"\x13server/server.proto\"\xdf\x03\n" +
+	"\vHttpRequest\x12\x16\n" +
+	"\x06method\x18\x01 \x01(\tR\x06method\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x1b\n" +
+	"\traw_query\x18\x03 \x01(\tR\brawQuery\x123\n" +
+	"\aheaders\x18\x04 \x03(\v2\x19.HttpRequest.HeadersEntryR\aheaders\x12\x1f\n" +
+	"\vremote_addr\x18\x05 \x01(\tR\n" +
+	"remoteAddr\x12\x12\n" +
+	"\x04host\x18\x06 \x01(\tR\x04host\x12=\n" +
+	"\vpath_params\x18\a \x03(\v2\x1c.HttpRequest.PathParamsEntryR\n" +
+	"pathParams\x12%\n" +
+	"\bidentity\x18\b \x01(\v2\t.IdentityR\bidentity\x12-\n" +
+	"\twebsocket\x18\t \x01(\v2\x0f.WebSocketEventR\twebsocket\x1aI\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12#\n" +
+	"\x05value\x18\x02 \x01(\v2\r.HeaderValuesR\x05value:\x028\x01\x1a=\n" +
+	"\x0fPathParamsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"a\n" +
+	"\x0eWebSocketEvent\x12#\n" +
+	"\rconnection_id\x18\x01 \x01(\tR\fconnectionId\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x16\n" +
+	"\x06binary\x18\x03 \x01(\bR\x06binary\"r\n" +
+	"\bIdentity\x12\x16\n" +
+	"\x06scheme\x18\x01 \x01(\tR\x06scheme\x12\x1c\n" +
+	"\n" +
+	"api_key_id\x18\x02 \x01(\tR\bapiKeyId\x12\x18\n" +
+	"\asubject\x18\x03 \x01(\tR\asubject\x12\x16\n" +
+	"\x06claims\x18\x04 \x01(\fR\x06claims\"\xb0\x01\n" +
+	"\fHttpResponse\x12\x1f\n" +
+	"\vstatus_code\x18\x01 \x01(\x05R\n" +
+	"statusCode\x124\n" +
+	"\aheaders\x18\x02 \x03(\v2\x1a.HttpResponse.HeadersEntryR\aheaders\x1aI\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12#\n" +
+	"\x05value\x18\x02 \x01(\v2\r.HeaderValuesR\x05value:\x028\x01\"&\n" +
+	"\fHeaderValues\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\tR\x06values\"\x8a\x01\n" +
 	"\rInvokeRequest\x12\x18\n" +
-	"\apayload\x18\x01 \x01(\tR\apayload\"&\n" +
+	"\apayload\x18\x01 \x01(\fR\apayload\x12 \n" +
+	"\x04http\x18\x04 \x01(\v2\f.HttpRequestR\x04http\x12!\n" +
+	"\fcontent_type\x18\x05 \x01(\tR\vcontentTypeJ\x04\b\x02\x10\x03J\x04\b\x03\x10\x04R\x06paramsR\x06method\"l\n" +
 	"\fInvokeResult\x12\x16\n" +
-	"\x06output\x18\x01 \x01(\tR\x06output2@\n" +
+	"\x06output\x18\x01 \x01(\fR\x06output\x12!\n" +
+	"\x04http\x18\x02 \x01(\v2\r.HttpResponseR\x04http\x12!\n" +
+	"\fcontent_type\x18\x03 \x01(\tR\vcontentType\"\\\n" +
+	"\vErrorDetail\x12\x1f\n" +
+	"\vhttp_status\x18\x01 \x01(\x05R\n" +
+	"httpStatus\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"g\n" +
+	"\vInvokeChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12!\n" +
+	"\x04http\x18\x02 \x01(\v2\r.HttpResponseR\x04http\x12!\n" +
+	"\fcontent_type\x18\x03 \x01(\tR\vcontentType2p\n" +
 	"\x15FunctionRunnerService\x12'\n" +
-	"\x06Invoke\x12\x0e.InvokeRequest\x1a\r.InvokeResultB,Z*github.com/Ow1Dev/noctifunc/pkg/api/serverb\x06proto3"
+	"\x06Invoke\x12\x0e.InvokeRequest\x1a\r.InvokeResult\x12.\n" +
+	"\fInvokeStream\x12\x0e.InvokeRequest\x1a\f.InvokeChunk0\x01B,Z*github.com/Ow1Dev/NoctiFunc/pkg/api/serverb\x06proto3"
 
 var (
 	file_server_server_proto_rawDescOnce sync.Once
@@ -134,19 +683,41 @@ func file_server_server_proto_rawDescGZIP() []byte {
 	return file_server_server_proto_rawDescData
 }
 
-var file_server_server_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_server_server_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_server_server_proto_goTypes = []any{
-	(*InvokeRequest)(nil), // 0: InvokeRequest
-	(*InvokeResult)(nil),  // 1: InvokeResult
+	(*HttpRequest)(nil),    // 0: HttpRequest
+	(*WebSocketEvent)(nil), // 1: WebSocketEvent
+	(*Identity)(nil),       // 2: Identity
+	(*HttpResponse)(nil),   // 3: HttpResponse
+	(*HeaderValues)(nil),   // 4: HeaderValues
+	(*InvokeRequest)(nil),  // 5: InvokeRequest
+	(*InvokeResult)(nil),   // 6: InvokeResult
+	(*ErrorDetail)(nil),    // 7: ErrorDetail
+	(*InvokeChunk)(nil),    // 8: InvokeChunk
+	nil,                    // 9: HttpRequest.HeadersEntry
+	nil,                    // 10: HttpRequest.PathParamsEntry
+	nil,                    // 11: HttpResponse.HeadersEntry
 }
 var file_server_server_proto_depIdxs = []int32{
-	0, // 0: FunctionRunnerService.Invoke:input_type -> InvokeRequest
-	1, // 1: FunctionRunnerService.Invoke:output_type -> InvokeResult
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	9,  // 0: HttpRequest.headers:type_name -> HttpRequest.HeadersEntry
+	10, // 1: HttpRequest.path_params:type_name -> HttpRequest.PathParamsEntry
+	2,  // 2: HttpRequest.identity:type_name -> Identity
+	1,  // 3: HttpRequest.websocket:type_name -> WebSocketEvent
+	11, // 4: HttpResponse.headers:type_name -> HttpResponse.HeadersEntry
+	0,  // 5: InvokeRequest.http:type_name -> HttpRequest
+	3,  // 6: InvokeResult.http:type_name -> HttpResponse
+	3,  // 7: InvokeChunk.http:type_name -> HttpResponse
+	4,  // 8: HttpRequest.HeadersEntry.value:type_name -> HeaderValues
+	4,  // 9: HttpResponse.HeadersEntry.value:type_name -> HeaderValues
+	5,  // 10: FunctionRunnerService.Invoke:input_type -> InvokeRequest
+	5,  // 11: FunctionRunnerService.InvokeStream:input_type -> InvokeRequest
+	6,  // 12: FunctionRunnerService.Invoke:output_type -> InvokeResult
+	8,  // 13: FunctionRunnerService.InvokeStream:output_type -> InvokeChunk
+	12, // [12:14] is the sub-list for method output_type
+	10, // [10:12] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_server_server_proto_init() }
@@ -160,7 +731,7 @@ func file_server_server_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_server_server_proto_rawDesc), len(file_server_server_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -20,7 +20,8 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	FunctionRunnerService_Invoke_FullMethodName = "/FunctionRunnerService/Invoke"
+	FunctionRunnerService_Invoke_FullMethodName       = "/FunctionRunnerService/Invoke"
+	FunctionRunnerService_InvokeStream_FullMethodName = "/FunctionRunnerService/InvokeStream"
 )
 
 // FunctionRunnerServiceClient is the client API for FunctionRunnerService service.
@@ -28,6 +29,8 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type FunctionRunnerServiceClient interface {
 	Invoke(ctx context.Context, in *InvokeRequest, opts ...grpc.CallOption) (*InvokeResult, error)
+	// InvokeStream sends the function's output as it is produced.
+	InvokeStream(ctx context.Context, in *InvokeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InvokeChunk], error)
 }
 
 type functionRunnerServiceClient struct {
@@ -48,11 +51,32 @@ func (c *functionRunnerServiceClient) Invoke(ctx context.Context, in *InvokeRequ
 	return out, nil
 }
 
+func (c *functionRunnerServiceClient) InvokeStream(ctx context.Context, in *InvokeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InvokeChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FunctionRunnerService_ServiceDesc.Streams[0], FunctionRunnerService_InvokeStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[InvokeRequest, InvokeChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FunctionRunnerService_InvokeStreamClient = grpc.ServerStreamingClient[InvokeChunk]
+
 // FunctionRunnerServiceServer is the server API for FunctionRunnerService service.
 // All implementations must embed UnimplementedFunctionRunnerServiceServer
 // for forward compatibility.
 type FunctionRunnerServiceServer interface {
 	Invoke(context.Context, *InvokeRequest) (*InvokeResult, error)
+	// InvokeStream sends the function's output as it is produced.
+	InvokeStream(*InvokeRequest, grpc.ServerStreamingServer[InvokeChunk]) error
 	mustEmbedUnimplementedFunctionRunnerServiceServer()
 }
 
@@ -66,6 +90,9 @@ type UnimplementedFunctionRunnerServiceServer struct{}
 func (UnimplementedFunctionRunnerServiceServer) Invoke(context.Context, *InvokeRequest) (*InvokeResult, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Invoke not implemented")
 }
+func (UnimplementedFunctionRunnerServiceServer) InvokeStream(*InvokeRequest, grpc.ServerStreamingServer[InvokeChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method InvokeStream not implemented")
+}
 func (UnimplementedFunctionRunnerServiceServer) mustEmbedUnimplementedFunctionRunnerServiceServer() {}
 func (UnimplementedFunctionRunnerServiceServer) testEmbeddedByValue()                               {}
 
@@ -105,6 +132,17 @@ func _FunctionRunnerService_Invoke_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _FunctionRunnerService_InvokeStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(InvokeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FunctionRunnerServiceServer).InvokeStream(m, &grpc.GenericServerStream[InvokeRequest, InvokeChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FunctionRunnerService_InvokeStreamServer = grpc.ServerStreamingServer[InvokeChunk]
+
 // FunctionRunnerService_ServiceDesc is the grpc.ServiceDesc for FunctionRunnerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -117,6 +155,12 @@ var FunctionRunnerService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _FunctionRunnerService_Invoke_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "InvokeStream",
+			Handler:       _FunctionRunnerService_InvokeStream_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "server/server.proto",
 }
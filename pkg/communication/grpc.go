@@ -2,54 +2,158 @@ package communication
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"time"
+	"io"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
 )
 
+// DefaultMaxBodyBytes is the largest request or response body carried
+// between Prism, igniterelay and functions unless configured otherwise.
+const DefaultMaxBodyBytes = 4 << 20
+
+// RelayAddrEnv is the environment variable that tells functions where to
+// reach igniterelay, for example to post to WebSocket connections.
+const RelayAddrEnv = "NOCTIFUNC_RELAY_ADDR"
+
+// messageOverhead leaves room for the action name and HTTP envelope next to the body.
+const messageOverhead = 1 << 20
+
+// MaxMessageSize returns the gRPC message size needed to carry bodies of up to maxBodyBytes.
+func MaxMessageSize(maxBodyBytes int64) int {
+	return int(maxBodyBytes) + messageOverhead
+}
+
 type GRPCClient struct {
-	address string
-	timeout time.Duration
+	address        string
+	maxMessageSize int
 }
 
-func NewGRPCClient(address string, timeout time.Duration) *GRPCClient {
+func NewGRPCClient(address string, maxMessageSize int) *GRPCClient {
 	return &GRPCClient{
-		address: address,
-		timeout: timeout,
+		address:        address,
+		maxMessageSize: maxMessageSize,
 	}
 }
 
-func (c *GRPCClient) SendAction(ctx context.Context, action, body string) (string, error) {
-	conn, err := grpc.NewClient(c.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// SendAction executes req on igniterelay. The deadline of ctx is sent along
+// and bounds the whole invocation, including a cold start of the function.
+func (c *GRPCClient) SendAction(ctx context.Context, req *pb.ExecuteRequest) (*pb.ExecuteResponse, error) {
+	var resp *pb.ExecuteResponse
+	err := c.call(func(client pb.CommunicationServiceClient) error {
+		var err error
+		resp, err = client.Execute(ctx, req)
+		return err
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to connect to gRPC server: %w", err)
+		return nil, fmt.Errorf("failed to send action to remote service: %w", err)
 	}
-	defer func() {
-		if err := conn.Close(); err != nil {
-			fmt.Printf("error closing connection: %v\n", err)
+
+	return resp, nil
+}
+
+// SendActionStream executes req on igniterelay and passes each chunk of the
+// function's output to send as it arrives.
+func (c *GRPCClient) SendActionStream(ctx context.Context, req *pb.ExecuteRequest, send func(*pb.ExecuteChunk) error) error {
+	err := c.call(func(client pb.CommunicationServiceClient) error {
+		stream, err := client.ExecuteStream(ctx, req)
+		if err != nil {
+			return err
 		}
-	}()
+		for {
+			chunk, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := send(chunk); err != nil {
+				return err
+			}
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to stream action from remote service: %w", err)
+	}
+	return nil
+}
 
-	client := pb.NewCommunicationServiceClient(conn)
+// SendActionAsync starts req on igniterelay without waiting for it to finish.
+func (c *GRPCClient) SendActionAsync(ctx context.Context, req *pb.ExecuteRequest) (*pb.AsyncInvocation, error) {
+	var inv *pb.AsyncInvocation
+	err := c.call(func(client pb.CommunicationServiceClient) error {
+		var err error
+		inv, err = client.ExecuteAsync(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send action to remote service: %w", err)
+	}
+	return inv, nil
+}
 
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
-	defer cancel()
+// GetInvocation returns the state of an invocation started with SendActionAsync.
+func (c *GRPCClient) GetInvocation(ctx context.Context, id string) (*pb.AsyncInvocation, error) {
+	var inv *pb.AsyncInvocation
+	err := c.call(func(client pb.CommunicationServiceClient) error {
+		var err error
+		inv, err = client.GetInvocation(ctx, &pb.GetInvocationRequest{Id: id})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invocation from remote service: %w", err)
+	}
+	return inv, nil
+}
 
-	resp, err := client.Execute(ctx, &pb.ExecuteRequest{
-		Action: action,
-		Body:   body,
+// Health checks that igniterelay reports SERVING through the gRPC health
+// service, which it does only while it can start functions.
+func (c *GRPCClient) Health(ctx context.Context) error {
+	var resp *healthpb.HealthCheckResponse
+	err := c.dial(func(conn *grpc.ClientConn) error {
+		var err error
+		resp, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+		return err
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to send action to remote service: %w", err)
+		return fmt.Errorf("failed to check health of remote service: %w", err)
 	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("remote service is %s", resp.GetStatus())
+	}
+	return nil
+}
 
-	if resp.Status != "success" {
-		return "", fmt.Errorf("remote service returned error status: %s", resp.Status)
+// call runs fn with a client connected to igniterelay.
+func (c *GRPCClient) call(fn func(pb.CommunicationServiceClient) error) error {
+	return c.dial(func(conn *grpc.ClientConn) error {
+		return fn(pb.NewCommunicationServiceClient(conn))
+	})
+}
+
+// dial runs fn with a connection to igniterelay.
+func (c *GRPCClient) dial(fn func(*grpc.ClientConn) error) error {
+	conn, err := grpc.NewClient(c.address,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallSendMsgSize(c.maxMessageSize),
+			grpc.MaxCallRecvMsgSize(c.maxMessageSize),
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to connect to gRPC server: %w", err)
 	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			fmt.Printf("error closing connection: %v\n", err)
+		}
+	}()
 
-	return resp.Resp, nil
+	return fn(conn)
 }
@@ -0,0 +1,37 @@
+package communication
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDHeader is the HTTP header Prism reads the request ID from and
+// echoes it in. The ID follows the request to igniterelay and the function
+// so their log lines can be matched up.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the gRPC metadata key carrying the request ID.
+const requestIDKey = "x-request-id"
+
+// WithRequestID returns a copy of ctx that sends id with outgoing gRPC calls.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, requestIDKey, id)
+}
+
+// RequestID returns the request ID ctx sends with outgoing gRPC calls or,
+// failing that, the one received with the incoming call. It is empty when
+// ctx carries none.
+func RequestID(ctx context.Context) string {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		if ids := md.Get(requestIDKey); len(ids) > 0 {
+			return ids[len(ids)-1]
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(requestIDKey); len(ids) > 0 {
+			return ids[0]
+		}
+	}
+	return ""
+}
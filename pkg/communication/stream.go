@@ -0,0 +1,20 @@
+package communication
+
+import (
+	"context"
+	"time"
+)
+
+// WithFirstChunkTimeout returns a copy of ctx that is canceled, with
+// context.DeadlineExceeded as its cause, unless the returned received func is
+// called within d. Streams use it so their timeout bounds the wait for the
+// function's first output rather than the whole stream.
+func WithFirstChunkTimeout(ctx context.Context, d time.Duration) (context.Context, func(), context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(d, func() { cancel(context.DeadlineExceeded) })
+	received := func() { timer.Stop() }
+	return ctx, received, func() {
+		timer.Stop()
+		cancel(context.Canceled)
+	}
+}
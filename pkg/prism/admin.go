@@ -0,0 +1,284 @@
+package prism
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Ow1Dev/NoctiFunc/pkg/utils"
+)
+
+// maxRouteFileBytes bounds route files uploaded through the admin API.
+const maxRouteFileBytes = 1 << 20
+
+// routeNamePattern matches route names, which become file names in the
+// routes directory and so must not contain path separators.
+var routeNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// WithAdminToken enables the admin endpoints under /_admin/ served by
+// AdminHandler, which require the token as a bearer token.
+func WithAdminToken(token string) Option {
+	return func(s *Server) {
+		s.adminToken = token
+	}
+}
+
+// WithFileWriter lets the admin API create, update and delete route files.
+// Without it, route files can only be read.
+func WithFileWriter(fileWriter FileWriter) Option {
+	return func(s *Server) {
+		s.fileWriter = fileWriter
+	}
+}
+
+// AdminHandler serves the admin API, meant for a listener that is not
+// reachable by clients: the routes under /_admin/routes along with the cache
+// and target endpoints. Every request needs the admin token, so nothing is
+// served while no token is set.
+func (s *Server) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /_admin/routes", s.adminOnly(s.handleListRoutes))
+	mux.HandleFunc("GET /_admin/routes/{name}", s.adminOnly(s.handleGetRoute))
+	mux.HandleFunc("PUT /_admin/routes/{name}", s.adminOnly(s.handlePutRoute))
+	mux.HandleFunc("DELETE /_admin/routes/{name}", s.adminOnly(s.handleDeleteRoute))
+	mux.HandleFunc("DELETE /_admin/cache", s.adminOnly(s.handlePurgeCache))
+	mux.HandleFunc("GET /_admin/targets", s.adminOnly(s.handleTargetStats))
+	return s.withAccessLog(mux)
+}
+
+// adminOnly rejects requests without the admin token before calling next.
+func (s *Server) adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.adminAuthorized(r) {
+			s.handleError(w, r, bearerError(http.StatusUnauthorized, "invalid_token", "Invalid admin token"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// adminAuthorized reports whether r carries the admin token.
+func (s *Server) adminAuthorized(r *http.Request) bool {
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	return s.adminToken != "" && strings.EqualFold(scheme, "Bearer") &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) == 1
+}
+
+// handlePurgeCache drops cached responses: those of one route with
+// ?route=/users/{id}, those of one path with ?path=/users/1, or all of them.
+func (s *Server) handlePurgeCache(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	route, path := query.Get("route"), query.Get("path")
+	purged := s.cache.purge(func(e *cacheEntry) bool {
+		return (route == "" || e.route == route) && (path == "" || e.path == path)
+	})
+	s.log(r.Context()).Info().Msgf("Purged %d cached responses", purged)
+
+	s.writeJSON(w, r, http.StatusOK, map[string]int{"purged": purged})
+}
+
+// RouteInfo describes a route file in the routes directory. A file that is
+// valid but not loaded conflicts with another route or has a request_schema
+// that does not compile; the log says which.
+type RouteInfo struct {
+	Name    string   `json:"name"` // the file name without its extension
+	File    string   `json:"file"`
+	Path    string   `json:"path,omitempty"`
+	Methods []string `json:"methods,omitempty"`
+	Action  string   `json:"action,omitempty"`
+	Loaded  bool     `json:"loaded"`
+	Error   string   `json:"error,omitempty"` // why the file is invalid
+}
+
+func (s *Server) handleListRoutes(w http.ResponseWriter, r *http.Request) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	if err := s.reloadRoutesLocked(); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	files := make([]string, 0, len(s.routeFiles))
+	for file := range s.routeFiles {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	routes := make([]RouteInfo, 0, len(files))
+	for _, file := range files {
+		routes = append(routes, s.routeInfo(file))
+	}
+	s.writeJSON(w, r, http.StatusOK, map[string][]RouteInfo{"routes": routes})
+}
+
+func (s *Server) handleGetRoute(w http.ResponseWriter, r *http.Request) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	if err := s.reloadRoutesLocked(); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	file, ok := s.routeFile(r.PathValue("name"))
+	if !ok {
+		s.handleError(w, r, routeNotFound(r.PathValue("name")))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	if _, err := io.WriteString(w, s.routeFiles[file]); err != nil {
+		s.log(r.Context()).Error().Err(err).Msg("Failed to write route file")
+	}
+}
+
+// handlePutRoute creates or replaces a route file with the request body,
+// which is the route in YAML or JSON. The route must be valid and must not
+// conflict with the other routes; the file is stored as sent.
+func (s *Server) handlePutRoute(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !routeNamePattern.MatchString(name) {
+		s.handleError(w, r, &HTTPError{
+			Code:      http.StatusBadRequest,
+			Message:   "Route names may contain letters, digits, '.', '_' and '-'",
+			ErrorCode: "invalid_route_name",
+		})
+		return
+	}
+	if s.fileWriter == nil {
+		s.handleError(w, r, &HTTPError{Code: http.StatusNotImplemented, Message: "Route files are read-only"})
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRouteFileBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			s.handleError(w, r, &HTTPError{Code: http.StatusRequestEntityTooLarge, Message: "Route file is too large"})
+			return
+		}
+		s.handleError(w, r, &HTTPError{Code: http.StatusBadRequest, Message: "Failed to read body"})
+		return
+	}
+
+	cfg, err := parseRouteConfig(data)
+	if err != nil {
+		s.handleError(w, r, invalidRoute(err))
+		return
+	}
+
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	// Check against the routes on disk, not a table that may be stale
+	if err := s.reloadRoutesLocked(); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	file, exists := s.routeFile(name)
+	if !exists {
+		file = name + ".yml"
+	}
+
+	if cfg.RequestSchema != nil {
+		schemaFiles := s.readSchemaFiles(map[string]string{file: string(data)})
+		if _, err := s.compileSchema(cfg.RequestSchema, schemaFiles, make(map[string]*compiledSchema)); err != nil {
+			s.handleError(w, r, invalidRoute(err))
+			return
+		}
+	}
+	if err := s.routes.Load().conflicts(cfg, file); err != nil {
+		s.handleError(w, r, &HTTPError{Code: http.StatusConflict, Message: err.Error(), ErrorCode: "route_conflict"})
+		return
+	}
+
+	if err := s.fileWriter.WriteFile(filepath.Join(s.routesPath, file), data); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+	if err := s.reloadRoutesLocked(); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+	s.log(r.Context()).Info().Msgf("Route file %s written through the admin API", file)
+
+	s.writeJSON(w, r, utils.Ternary(exists, http.StatusOK, http.StatusCreated), s.routeInfo(file))
+}
+
+func (s *Server) handleDeleteRoute(w http.ResponseWriter, r *http.Request) {
+	if s.fileWriter == nil {
+		s.handleError(w, r, &HTTPError{Code: http.StatusNotImplemented, Message: "Route files are read-only"})
+		return
+	}
+
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	if err := s.reloadRoutesLocked(); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	file, ok := s.routeFile(r.PathValue("name"))
+	if !ok {
+		s.handleError(w, r, routeNotFound(r.PathValue("name")))
+		return
+	}
+
+	if err := s.fileWriter.RemoveFile(filepath.Join(s.routesPath, file)); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+	if err := s.reloadRoutesLocked(); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+	s.log(r.Context()).Info().Msgf("Route file %s deleted through the admin API", file)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// routeFile returns the file of the named route. Callers hold reloadMu.
+func (s *Server) routeFile(name string) (string, bool) {
+	for _, ext := range []string{".yml", ".yaml"} {
+		if _, ok := s.routeFiles[name+ext]; ok {
+			return name + ext, true
+		}
+	}
+	return "", false
+}
+
+// routeInfo describes a file of the loaded routes. Callers hold reloadMu.
+func (s *Server) routeInfo(file string) RouteInfo {
+	info := RouteInfo{Name: strings.TrimSuffix(file, filepath.Ext(file)), File: file}
+
+	cfg, err := parseRouteConfig([]byte(s.routeFiles[file]))
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	info.Path, info.Methods, info.Action = cfg.RoutePath(), cfg.AllowedMethods(), cfg.Action
+
+	for _, rt := range s.routes.Load().routes {
+		if rt.source == file {
+			info.Loaded = true
+			break
+		}
+	}
+	return info
+}
+
+func routeNotFound(name string) *HTTPError {
+	return &HTTPError{Code: http.StatusNotFound, Message: "No route named " + name, ErrorCode: "route_not_found"}
+}
+
+func invalidRoute(err error) *HTTPError {
+	return &HTTPError{Code: http.StatusBadRequest, Message: err.Error(), ErrorCode: "invalid_route"}
+}
@@ -0,0 +1,187 @@
+package prism
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// MockFileWriter changes the files served by routeFiles.
+type MockFileWriter struct {
+	files map[string]string
+}
+
+func (m *MockFileWriter) WriteFile(filename string, data []byte) error {
+	m.files[filepath.Base(filename)] = string(data)
+	return nil
+}
+
+func (m *MockFileWriter) RemoveFile(filename string) error {
+	delete(m.files, filepath.Base(filename))
+	return nil
+}
+
+// newAdminServer serves files through the admin API with token "secret".
+func newAdminServer(t *testing.T, files map[string]string) *Server {
+	t.Helper()
+
+	return newTestServer(t, &MockCommunicationClient{}, routeFiles(files),
+		WithAdminToken("secret"), WithFileWriter(&MockFileWriter{files: files}))
+}
+
+func adminRequest(handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestServer_AdminRoutes(t *testing.T) {
+	files := map[string]string{
+		"users.yml": "path: /users/{id}\nmethod: GET\naction: users.get",
+	}
+	server := newAdminServer(t, files)
+	admin := server.AdminHandler()
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "create", method: http.MethodPut, target: "/_admin/routes/orders", body: "path: /orders\nmethod: POST\naction: orders.create", wantStatus: http.StatusCreated},
+		{name: "create from JSON", method: http.MethodPut, target: "/_admin/routes/items", body: `{"path": "/items", "method": "GET", "action": "items.list"}`, wantStatus: http.StatusCreated},
+		{name: "update", method: http.MethodPut, target: "/_admin/routes/orders", body: "path: /orders\nmethods: [GET, POST]\naction: orders", wantStatus: http.StatusOK},
+		{name: "invalid route", method: http.MethodPut, target: "/_admin/routes/broken", body: "path: /broken\nmethod: ERROR\naction: broken", wantStatus: http.StatusBadRequest, wantCode: "invalid_route"},
+		{name: "invalid name", method: http.MethodPut, target: "/_admin/routes/..hidden", body: "method: GET\naction: x", wantStatus: http.StatusBadRequest, wantCode: "invalid_route_name"},
+		{name: "conflict", method: http.MethodPut, target: "/_admin/routes/people", body: "path: /users/{name}\nmethod: GET\naction: people", wantStatus: http.StatusConflict, wantCode: "route_conflict"},
+		{name: "get", method: http.MethodGet, target: "/_admin/routes/users", wantStatus: http.StatusOK},
+		{name: "get missing", method: http.MethodGet, target: "/_admin/routes/missing", wantStatus: http.StatusNotFound, wantCode: "route_not_found"},
+		{name: "delete", method: http.MethodDelete, target: "/_admin/routes/items", wantStatus: http.StatusNoContent},
+		{name: "delete missing", method: http.MethodDelete, target: "/_admin/routes/items", wantStatus: http.StatusNotFound, wantCode: "route_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := adminRequest(admin, tt.method, tt.target, tt.body)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantCode != "" && !strings.Contains(w.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("Expected error code %s, got %s", tt.wantCode, w.Body.String())
+			}
+		})
+	}
+
+	if _, ok := files["people.yml"]; ok {
+		t.Error("Expected the conflicting route not to be written")
+	}
+	if _, ok := files["items.yml"]; ok {
+		t.Error("Expected the deleted route file to be removed")
+	}
+
+	// Changes are live without a reload
+	routes := server.routes.Load()
+	if _, _, err := routes.lookup(http.MethodGet, "/orders"); err != nil {
+		t.Errorf("Expected the updated route to serve GET /orders: %v", err)
+	}
+	if _, _, err := routes.lookup(http.MethodGet, "/items"); err == nil {
+		t.Error("Expected the deleted route to be gone")
+	}
+}
+
+func TestServer_AdminListRoutes(t *testing.T) {
+	server := newAdminServer(t, map[string]string{
+		"users.yml":  "path: /users/{id}\nmethod: GET\naction: users.get",
+		"people.yml": "path: /users/{name}\nmethod: GET\naction: people",
+		"bad.yaml":   "method: ERROR\naction: bad",
+	})
+
+	w := adminRequest(server.AdminHandler(), http.MethodGet, "/_admin/routes", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var body struct {
+		Routes []RouteInfo `json:"routes"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode routes: %v", err)
+	}
+
+	expected := map[string]struct {
+		loaded  bool
+		invalid bool
+	}{
+		"bad":    {loaded: false, invalid: true},
+		"people": {loaded: true},  // loaded first by name
+		"users":  {loaded: false}, // conflicts with people
+	}
+	if len(body.Routes) != len(expected) {
+		t.Fatalf("Expected %d routes, got %v", len(expected), body.Routes)
+	}
+	for _, info := range body.Routes {
+		want := expected[info.Name]
+		if info.Loaded != want.loaded || (info.Error != "") != want.invalid {
+			t.Errorf("Unexpected route info %+v", info)
+		}
+	}
+}
+
+func TestServer_AdminRequiresToken(t *testing.T) {
+	server := newAdminServer(t, map[string]string{})
+
+	req := httptest.NewRequest(http.MethodGet, "/_admin/routes", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	w := httptest.NewRecorder()
+	server.AdminHandler().ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	// Without a token configured, nothing is authorized
+	server.adminToken = ""
+	req = httptest.NewRequest(http.MethodGet, "/_admin/routes", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	w = httptest.NewRecorder()
+	server.AdminHandler().ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d without a configured token, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestOSFileWriter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "orders.yml")
+	writer := &OSFileWriter{}
+
+	for _, content := range []string{"method: GET\naction: a", "method: POST\naction: b"} {
+		if err := writer.WriteFile(path, []byte(content)); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != content {
+			t.Errorf("Expected file content %q, got %q (%v)", content, data, err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files to remain, got %d entries", len(entries))
+	}
+
+	if err := writer.RemoveFile(path); err != nil {
+		t.Errorf("Failed to remove file: %v", err)
+	}
+}
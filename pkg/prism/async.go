@@ -0,0 +1,202 @@
+package prism
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Invocation modes a route can default to with its invocation setting.
+const (
+	InvocationSync  = "sync"
+	InvocationAsync = "async"
+)
+
+// invocationTypeHeader lets clients choose the invocation mode per request.
+const invocationTypeHeader = "X-Invocation-Type"
+
+// invokeAsync reports whether r runs asynchronously. The X-Invocation-Type
+// header takes precedence over the route's default.
+func invokeAsync(r *http.Request, cfg *RouteConfig) (bool, error) {
+	switch mode := strings.ToLower(r.Header.Get(invocationTypeHeader)); mode {
+	case "":
+		return cfg.Invocation == InvocationAsync, nil
+	case InvocationSync:
+		return false, nil
+	case InvocationAsync:
+		return true, nil
+	default:
+		return false, &HTTPError{Code: http.StatusBadRequest, Message: "Unsupported " + invocationTypeHeader + ": " + mode}
+	}
+}
+
+// startAsync hands req to igniterelay to run in the background and answers
+// 202 with the invocation, whose status is served on its Location. 429 is
+// answered when igniterelay holds as many invocations as it may.
+func (s *Server) startAsync(w http.ResponseWriter, r *http.Request, rt *route, req *commpb.ExecuteRequest) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+
+	req.Route = r.Method + " " + rt.template.raw
+	req.TimeoutMs = rt.config.Timeout.Milliseconds()
+	inv, err := s.commClient.SendActionAsync(ctx, req)
+	if status.Code(err) == codes.ResourceExhausted {
+		s.handleError(w, r, &HTTPError{Code: http.StatusTooManyRequests, Message: "Too many asynchronous invocations"})
+		return
+	}
+	if err != nil {
+		s.handleError(w, r, actionError(req.GetAction(), err))
+		return
+	}
+
+	w.Header().Set("Location", "/_invocations/"+inv.GetId())
+	s.writeJSON(w, r, http.StatusAccepted, newInvocationResponse(inv))
+}
+
+// handleGetInvocation reports the status of an asynchronous invocation, with
+// its result or error once it has finished.
+func (s *Server) handleGetInvocation(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+
+	inv, err := s.commClient.GetInvocation(ctx, id)
+	if status.Code(err) == codes.NotFound {
+		s.handleError(w, r, &HTTPError{Code: http.StatusNotFound, Message: "Unknown invocation " + id})
+		return
+	}
+	if err != nil {
+		s.handleError(w, r, actionError("invocation", err))
+		return
+	}
+
+	if err := s.authorizeInvocation(r, inv); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	s.writeJSON(w, r, http.StatusOK, newInvocationResponse(inv))
+}
+
+// authorizeInvocation lets only the caller that started inv read it. The
+// caller is authenticated with the auth of the route that started inv, which
+// must still be served. Invocations of public routes are readable by anyone
+// who has their ID. Other callers are told the invocation does not exist.
+func (s *Server) authorizeInvocation(r *http.Request, inv *commpb.AsyncInvocation) error {
+	caller := inv.GetCaller()
+	if caller.GetScheme() == "" {
+		return nil
+	}
+
+	unknown := &HTTPError{Code: http.StatusNotFound, Message: "Unknown invocation " + inv.GetId()}
+	method, template, _ := strings.Cut(inv.GetRoute(), " ")
+	rt := s.routes.Load().find(method, template)
+	if rt == nil {
+		return unknown
+	}
+
+	identity, err := s.authenticate(r, rt)
+	if err != nil {
+		return err
+	}
+	if identity.GetScheme() != caller.GetScheme() || identity.GetApiKeyId() != caller.GetApiKeyId() || identity.GetSubject() != caller.GetSubject() {
+		return unknown
+	}
+	return nil
+}
+
+type invocationResponse struct {
+	ID          string            `json:"id"`
+	Action      string            `json:"action"`
+	Status      string            `json:"status"` // running, succeeded or failed
+	CreatedAt   time.Time         `json:"created_at"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+	Result      *invocationResult `json:"result,omitempty"`
+	Error       *invocationError  `json:"error,omitempty"`
+}
+
+// invocationResult is the response the function returned. JSON bodies are
+// embedded as is, other bodies are base64 encoded.
+type invocationResult struct {
+	StatusCode int             `json:"status_code"`
+	Headers    http.Header     `json:"headers,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	BodyBase64 []byte          `json:"body_base64,omitempty"`
+}
+
+// invocationError is the error response a synchronous call would have received.
+type invocationError struct {
+	StatusCode int    `json:"status_code"`
+	Code       string `json:"code,omitempty"`
+	Message    string `json:"message"`
+}
+
+func newInvocationResponse(inv *commpb.AsyncInvocation) *invocationResponse {
+	resp := &invocationResponse{
+		ID:        inv.GetId(),
+		Action:    inv.GetAction(),
+		CreatedAt: time.UnixMilli(inv.GetCreatedAt()).UTC(),
+	}
+	if inv.GetCompletedAt() != 0 {
+		completedAt := time.UnixMilli(inv.GetCompletedAt()).UTC()
+		resp.CompletedAt = &completedAt
+	}
+
+	switch inv.GetState() {
+	case commpb.AsyncInvocation_SUCCEEDED:
+		resp.Status = "succeeded"
+		resp.Result = newInvocationResult(inv.GetResult())
+	case commpb.AsyncInvocation_FAILED:
+		resp.Status = "failed"
+		st := status.New(codes.Code(inv.GetErrorCode()), inv.GetErrorMessage())
+		if detail := inv.GetErrorDetail(); detail != nil {
+			if withDetail, err := st.WithDetails(detail); err == nil {
+				st = withDetail
+			}
+		}
+		httpErr := actionError(inv.GetAction(), st.Err())
+		resp.Error = &invocationError{StatusCode: httpErr.Code, Code: httpErr.ErrorCode, Message: httpErr.Message}
+	default:
+		resp.Status = "running"
+	}
+	return resp
+}
+
+func newInvocationResult(result *commpb.ExecuteResponse) *invocationResult {
+	header := responseHeader(result)
+	res := &invocationResult{
+		StatusCode: http.StatusOK,
+		Headers:    header,
+	}
+	if code := int(result.GetHttp().GetStatusCode()); code != 0 {
+		res.StatusCode = code
+	}
+
+	body := result.GetResp()
+	if len(body) == 0 {
+		return res
+	}
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) && json.Valid(body) {
+		res.Body = body
+	} else {
+		res.BodyBase64 = body
+	}
+	return res
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, r *http.Request, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.log(r.Context()).Error().Err(err).Msg("Failed to write response")
+	}
+}
@@ -0,0 +1,205 @@
+package prism
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServer_HandleAction_Async(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		header     string
+		wantStatus int
+		wantAsync  bool
+		timeoutMs  int64
+	}{
+		{name: "sync by default", file: "path: /jobs\nmethod: POST\naction: jobs", wantStatus: http.StatusOK},
+		{name: "async header", file: "path: /jobs\nmethod: POST\naction: jobs", header: "async", wantStatus: http.StatusAccepted, wantAsync: true},
+		{name: "async route", file: "path: /jobs\nmethod: POST\naction: jobs\ninvocation: async\ntimeout: 10m", wantStatus: http.StatusAccepted, wantAsync: true, timeoutMs: 600000},
+		{name: "sync header on async route", file: "path: /jobs\nmethod: POST\naction: jobs\ninvocation: async", header: "sync", wantStatus: http.StatusOK},
+		{name: "unsupported header", file: "path: /jobs\nmethod: POST\naction: jobs", header: "later", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var asyncBody, asyncRoute string
+			var asyncTimeout int64
+			commClient := &MockCommunicationClient{
+				SendActionAsyncFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.AsyncInvocation, error) {
+					asyncBody = string(req.GetBody())
+					asyncRoute = req.GetRoute()
+					asyncTimeout = req.GetTimeoutMs()
+					return &commpb.AsyncInvocation{Id: "inv-1", Action: req.GetAction(), State: commpb.AsyncInvocation_RUNNING, CreatedAt: 1700000000000}, nil
+				},
+			}
+			server := newTestServer(t, commClient, routeFiles(map[string]string{"jobs.yml": tt.file}))
+
+			req := httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"job": 1}`))
+			if tt.header != "" {
+				req.Header.Set("X-Invocation-Type", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			server.handleAction(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status code %d, got %d", tt.wantStatus, w.Code)
+			}
+			if !tt.wantAsync {
+				return
+			}
+			if asyncRoute != "POST /jobs" {
+				t.Errorf("Expected route 'POST /jobs', got '%s'", asyncRoute)
+			}
+			if asyncTimeout != tt.timeoutMs {
+				t.Errorf("Expected timeout %dms, got %dms", tt.timeoutMs, asyncTimeout)
+			}
+			if asyncBody != `{"job": 1}` {
+				t.Errorf("Expected body to be forwarded, got '%s'", asyncBody)
+			}
+			if got := w.Header().Get("Location"); got != "/_invocations/inv-1" {
+				t.Errorf("Expected Location '/_invocations/inv-1', got '%s'", got)
+			}
+			var resp invocationResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.ID != "inv-1" || resp.Status != "running" || resp.Action != "jobs" {
+				t.Errorf("Expected running invocation inv-1 of jobs, got %+v", resp)
+			}
+		})
+	}
+}
+
+func TestServer_GetInvocation(t *testing.T) {
+	failed, _ := status.New(codes.InvalidArgument, "bad job").WithDetails(&pb.ErrorDetail{HttpStatus: 422, Code: "bad_job", Message: "Job is invalid"})
+
+	invocations := map[string]*commpb.AsyncInvocation{
+		"running": {Id: "running", Action: "jobs", State: commpb.AsyncInvocation_RUNNING},
+		"json": {Id: "json", Action: "jobs", State: commpb.AsyncInvocation_SUCCEEDED, CompletedAt: 1700000000000, Result: &commpb.ExecuteResponse{
+			Resp: []byte(`{"done":true}`),
+			Http: &pb.HttpResponse{StatusCode: http.StatusCreated},
+		}},
+		"binary": {Id: "binary", Action: "jobs", State: commpb.AsyncInvocation_SUCCEEDED, Result: &commpb.ExecuteResponse{
+			Resp:        []byte{0xff, 0x00},
+			ContentType: "application/octet-stream",
+		}},
+		"failed": {
+			Id: "failed", Action: "jobs", State: commpb.AsyncInvocation_FAILED,
+			ErrorCode: int32(codes.InvalidArgument), ErrorMessage: "bad job", ErrorDetail: failed.Details()[0].(*pb.ErrorDetail),
+		},
+		"timed-out": {
+			Id: "timed-out", Action: "jobs", State: commpb.AsyncInvocation_FAILED,
+			ErrorCode: int32(codes.DeadlineExceeded), ErrorMessage: "action jobs timed out",
+		},
+	}
+	commClient := &MockCommunicationClient{
+		GetInvocationFunc: func(ctx context.Context, id string) (*commpb.AsyncInvocation, error) {
+			if inv, ok := invocations[id]; ok {
+				return inv, nil
+			}
+			return nil, status.Error(codes.NotFound, "unknown invocation")
+		},
+	}
+	handler := newTestServer(t, commClient, &MockFileReader{}).Handler()
+
+	tests := []struct {
+		id         string
+		wantStatus int
+		wantBody   string
+	}{
+		{id: "running", wantStatus: http.StatusOK, wantBody: `"status":"running"`},
+		{id: "json", wantStatus: http.StatusOK, wantBody: `"result":{"status_code":201,"headers":{"Content-Type":["application/json"]},"body":{"done":true}}`},
+		{id: "binary", wantStatus: http.StatusOK, wantBody: `"body_base64":"/wA="`},
+		{id: "failed", wantStatus: http.StatusOK, wantBody: `"error":{"status_code":422,"code":"bad_job","message":"Job is invalid"}`},
+		{id: "timed-out", wantStatus: http.StatusOK, wantBody: `"error":{"status_code":504,"message":"Action jobs timed out"}`},
+		{id: "missing", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/_invocations/"+tt.id, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status code %d, got %d", tt.wantStatus, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("Expected body to contain '%s', got '%s'", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_HandleAction_AsyncLimit(t *testing.T) {
+	commClient := &MockCommunicationClient{
+		SendActionAsyncFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.AsyncInvocation, error) {
+			return nil, status.Error(codes.ResourceExhausted, "too many invocations")
+		},
+	}
+	server := newTestServer(t, commClient, routeFiles(map[string]string{
+		"jobs.yml": "path: /jobs\nmethod: POST\naction: jobs\ninvocation: async",
+	}))
+
+	w := httptest.NewRecorder()
+	server.handleAction(w, httptest.NewRequest(http.MethodPost, "/jobs", nil))
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status code %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+}
+
+func TestServer_GetInvocation_Caller(t *testing.T) {
+	invocations := map[string]*commpb.AsyncInvocation{
+		"mine":   {Id: "mine", Action: "orders", Route: "POST /orders", Caller: &pb.Identity{Scheme: AuthAPIKey, ApiKeyId: "all"}},
+		"theirs": {Id: "theirs", Action: "orders", Route: "POST /orders", Caller: &pb.Identity{Scheme: AuthAPIKey, ApiKeyId: "other"}},
+		"gone":   {Id: "gone", Action: "orders", Route: "POST /removed", Caller: &pb.Identity{Scheme: AuthAPIKey, ApiKeyId: "all"}},
+		"public": {Id: "public", Action: "public", Route: "GET /public"},
+	}
+	commClient := &MockCommunicationClient{
+		GetInvocationFunc: func(ctx context.Context, id string) (*commpb.AsyncInvocation, error) {
+			return invocations[id], nil
+		},
+	}
+	server, secrets := newAPIKeyServer(t, commClient)
+	handler := server.Handler()
+
+	tests := []struct {
+		name       string
+		id         string
+		key        string
+		wantStatus int
+	}{
+		{name: "same caller", id: "mine", key: secrets["all"], wantStatus: http.StatusOK},
+		{name: "missing key", id: "mine", wantStatus: http.StatusUnauthorized},
+		{name: "key not allowed on the route", id: "mine", key: secrets["users-only"], wantStatus: http.StatusForbidden},
+		{name: "other caller", id: "theirs", key: secrets["all"], wantStatus: http.StatusNotFound},
+		{name: "route removed", id: "gone", key: secrets["all"], wantStatus: http.StatusNotFound},
+		{name: "public route", id: "public", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/_invocations/"+tt.id, nil)
+			if tt.key != "" {
+				req.Header.Set("X-Api-Key", tt.key)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status code %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package prism
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Ow1Dev/NoctiFunc/internal/apikey"
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// Authentication schemes a route can require with its auth setting.
+const (
+	AuthAPIKey = "api_key"
+	AuthJWT    = "jwt"
+)
+
+const (
+	apiKeyHeader     = "X-Api-Key"
+	apiKeyQueryParam = "api_key"
+)
+
+// WithJWKS enables jwt routes, verifying tokens with the keys published at
+// source, which is a local file path or an http(s) URL.
+func WithJWKS(source string) Option {
+	return func(s *Server) {
+		s.jwks = newJWKSCache(source, s.fileReader)
+	}
+}
+
+// WithAPIKeyStore enables api_key routes, checking keys against the key store at path.
+func WithAPIKeyStore(path string) Option {
+	return func(s *Server) {
+		s.apiKeysPath = path
+	}
+}
+
+// ReloadAPIKeys re-reads the key store if it changed since the last load. A
+// missing key store rejects every key.
+func (s *Server) ReloadAPIKeys() error {
+	if s.apiKeysPath == "" {
+		return nil
+	}
+
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	data, err := s.fileReader.ReadFile(s.apiKeysPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error reading key store: %w", err)
+	}
+
+	if s.apiKeys.Load() != nil && string(data) == s.apiKeysData {
+		return nil
+	}
+
+	store, err := apikey.Parse(data)
+	if err != nil {
+		return err
+	}
+	s.apiKeys.Store(store)
+	s.apiKeysData = string(data)
+
+	s.logger.Info().Msgf("Loaded %d API keys from %s", len(store.Keys), s.apiKeysPath)
+	return nil
+}
+
+// authenticate checks r against the authentication the route requires and
+// returns the identity forwarded to the function, or nil on public routes.
+func (s *Server) authenticate(r *http.Request, rt *route) (*pb.Identity, error) {
+	switch rt.config.Auth {
+	case "":
+		return nil, nil
+	case AuthAPIKey:
+		return s.authenticateAPIKey(r, rt)
+	case AuthJWT:
+		return s.authenticateJWT(r, rt)
+	default:
+		return nil, fmt.Errorf("unsupported auth scheme: %s", rt.config.Auth)
+	}
+}
+
+// authenticateAPIKey accepts a key from the X-Api-Key header or the api_key
+// query parameter. The key is removed from the request before it is
+// forwarded so functions never see it.
+func (s *Server) authenticateAPIKey(r *http.Request, rt *route) (*pb.Identity, error) {
+	secret := r.Header.Get(apiKeyHeader)
+	query := r.URL.Query()
+	if secret == "" {
+		secret = query.Get(apiKeyQueryParam)
+	}
+
+	r.Header.Del(apiKeyHeader)
+	if query.Has(apiKeyQueryParam) {
+		query.Del(apiKeyQueryParam)
+		r.URL.RawQuery = query.Encode()
+	}
+
+	if secret == "" {
+		return nil, &HTTPError{Code: http.StatusUnauthorized, Message: "Missing API key"}
+	}
+
+	store := s.apiKeys.Load()
+	if store == nil {
+		return nil, &HTTPError{Code: http.StatusUnauthorized, Message: "Invalid API key"}
+	}
+
+	key, err := store.Lookup(secret, time.Now())
+	if errors.Is(err, apikey.ErrExpiredKey) {
+		return nil, &HTTPError{Code: http.StatusUnauthorized, Message: "API key has expired"}
+	}
+	if err != nil {
+		return nil, &HTTPError{Code: http.StatusUnauthorized, Message: "Invalid API key"}
+	}
+
+	if !key.AllowsRoute(rt.template.raw) {
+		return nil, &HTTPError{Code: http.StatusForbidden, Message: "API key is not allowed to call " + rt.template.raw}
+	}
+
+	return &pb.Identity{Scheme: AuthAPIKey, ApiKeyId: key.ID}, nil
+}
+
+// jwtAlgorithms are the signature algorithms accepted for bearer tokens.
+var jwtAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// authenticateJWT verifies the bearer token in the Authorization header
+// against the route's issuer, audience and scopes.
+func (s *Server) authenticateJWT(r *http.Request, rt *route) (*pb.Identity, error) {
+	cfg := rt.config.JWT
+
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, bearerError(http.StatusUnauthorized, "", "Missing bearer token")
+	}
+	if s.jwks == nil {
+		s.log(r.Context()).Error().Msgf("Route %s requires jwt but no JWKS is configured", rt.template.raw)
+		return nil, bearerError(http.StatusUnauthorized, "invalid_token", "Token cannot be verified")
+	}
+
+	parsed, err := jwt.ParseSigned(token, jwtAlgorithms)
+	if err != nil || len(parsed.Headers) != 1 {
+		return nil, bearerError(http.StatusUnauthorized, "invalid_token", "Malformed token")
+	}
+
+	keys, err := s.jwks.key(r.Context(), parsed.Headers[0].KeyID, parsed.Headers[0].Algorithm)
+	if err != nil {
+		s.log(r.Context()).Error().Err(err).Msg("Failed to load JWKS")
+		return nil, bearerError(http.StatusUnauthorized, "invalid_token", "Token cannot be verified")
+	}
+
+	var claims jwt.Claims
+	var raw map[string]any
+	verified := false
+	for _, key := range keys {
+		if parsed.Claims(key.Key, &claims, &raw) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, bearerError(http.StatusUnauthorized, "invalid_token", "Invalid token signature")
+	}
+
+	if claims.Expiry == nil {
+		return nil, bearerError(http.StatusUnauthorized, "invalid_token", "Token has no expiry")
+	}
+	expected := jwt.Expected{Issuer: cfg.Issuer, AnyAudience: jwt.Audience{cfg.Audience}, Time: time.Now()}
+	if err := claims.Validate(expected); err != nil {
+		return nil, bearerError(http.StatusUnauthorized, "invalid_token", "Invalid token: "+err.Error())
+	}
+
+	granted := tokenScopes(raw)
+	for _, scope := range cfg.Scopes {
+		if !slices.Contains(granted, scope) {
+			return nil, bearerError(http.StatusForbidden, "insufficient_scope", "Token is missing scope "+scope, cfg.Scopes...)
+		}
+	}
+
+	claimsJSON, err := json.Marshal(raw)
+	if err != nil {
+		return nil, bearerError(http.StatusUnauthorized, "invalid_token", "Invalid token claims")
+	}
+	return &pb.Identity{Scheme: AuthJWT, Subject: claims.Subject, Claims: claimsJSON}, nil
+}
+
+// tokenScopes reads the granted scopes from the space separated "scope"
+// claim or the "scp" claim, which some providers send as a list.
+func tokenScopes(claims map[string]any) []string {
+	switch scp := claims["scp"].(type) {
+	case string:
+		return strings.Fields(scp)
+	case []any:
+		scopes := make([]string, 0, len(scp))
+		for _, v := range scp {
+			if s, ok := v.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+		return scopes
+	}
+	scope, _ := claims["scope"].(string)
+	return strings.Fields(scope)
+}
+
+// bearerError builds a 401 or 403 with the WWW-Authenticate challenge from
+// RFC 6750. errCode is empty when the request carried no token at all.
+func bearerError(code int, errCode, message string, scopes ...string) *HTTPError {
+	challenge := `Bearer realm="prism"`
+	if errCode != "" {
+		challenge += fmt.Sprintf(`, error=%q, error_description=%q`, errCode, message)
+	}
+	if len(scopes) > 0 {
+		challenge += fmt.Sprintf(`, scope=%q`, strings.Join(scopes, " "))
+	}
+
+	return &HTTPError{
+		Code:    code,
+		Message: message,
+		Header:  http.Header{"Www-Authenticate": {challenge}},
+	}
+}
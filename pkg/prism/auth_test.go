@@ -0,0 +1,414 @@
+package prism
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Ow1Dev/NoctiFunc/internal/apikey"
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+)
+
+const testKeyStorePath = "/test/keys/store"
+
+// newAPIKeyServer serves an api_key route on /orders and a public route on
+// /public, and returns secrets for an unrestricted, a restricted and an expired key.
+func newAPIKeyServer(t *testing.T, commClient CommunicationClient) (*Server, map[string]string) {
+	t.Helper()
+
+	now := time.Now()
+	expired := now.Add(-time.Hour)
+	store, _ := apikey.Parse(nil)
+	secrets := make(map[string]string)
+	for _, k := range []struct {
+		id        string
+		routes    []string
+		expiresAt *time.Time
+	}{
+		{id: "all"},
+		{id: "users-only", routes: []string{"/users/{id}"}},
+		{id: "expired", expiresAt: &expired},
+	} {
+		secret, _, err := store.Mint(k.id, k.routes, k.expiresAt, now)
+		if err != nil {
+			t.Fatalf("Failed to mint key: %v", err)
+		}
+		secrets[k.id] = secret
+	}
+	storeData, err := yaml.Marshal(store)
+	if err != nil {
+		t.Fatalf("Failed to encode key store: %v", err)
+	}
+
+	routes := routeFiles(map[string]string{
+		"orders.yml": "path: /orders\nmethod: POST\naction: orders\nauth: api_key",
+		"public.yml": "path: /public\nmethod: GET\naction: public",
+	})
+	readRoute := routes.ReadFileFunc
+	routes.ReadFileFunc = func(filename string) ([]byte, error) {
+		if filename == testKeyStorePath {
+			return storeData, nil
+		}
+		return readRoute(filename)
+	}
+
+	server := NewServer(commClient, routes, "/test/routes", zerolog.Nop(), WithAPIKeyStore(testKeyStorePath))
+	if err := server.ReloadRoutes(); err != nil {
+		t.Fatalf("Failed to load routes: %v", err)
+	}
+	if err := server.ReloadAPIKeys(); err != nil {
+		t.Fatalf("Failed to load API keys: %v", err)
+	}
+	return server, secrets
+}
+
+func TestServer_APIKeyAuth(t *testing.T) {
+	var got *pb.HttpRequest
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			got = req.GetHttp()
+			return &commpb.ExecuteResponse{}, nil
+		},
+	}
+	server, secrets := newAPIKeyServer(t, commClient)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		header string
+		code   int
+		keyID  string
+	}{
+		{name: "header", method: "POST", target: "/orders", header: secrets["all"], code: http.StatusOK, keyID: "all"},
+		{name: "query parameter", method: "POST", target: "/orders?api_key=" + secrets["all"] + "&page=2", code: http.StatusOK, keyID: "all"},
+		{name: "missing key", method: "POST", target: "/orders", code: http.StatusUnauthorized},
+		{name: "unknown key", method: "POST", target: "/orders", header: "nfk_unknown", code: http.StatusUnauthorized},
+		{name: "expired key", method: "POST", target: "/orders", header: secrets["expired"], code: http.StatusUnauthorized},
+		{name: "route not allowed", method: "POST", target: "/orders", header: secrets["users-only"], code: http.StatusForbidden},
+		{name: "public route", method: "GET", target: "/public", code: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Api-Key", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			server.handleAction(w, req)
+
+			if w.Code != tt.code {
+				t.Fatalf("Expected status code %d, got %d", tt.code, w.Code)
+			}
+			if tt.code != http.StatusOK {
+				if got != nil {
+					t.Error("Expected action not to be called")
+				}
+				return
+			}
+			if got.GetIdentity().GetApiKeyId() != tt.keyID {
+				t.Errorf("Expected key ID '%s', got '%s'", tt.keyID, got.GetIdentity().GetApiKeyId())
+			}
+			if _, ok := got.GetHeaders()["X-Api-Key"]; ok {
+				t.Error("Expected X-Api-Key header not to be forwarded")
+			}
+			if tt.name == "query parameter" && got.GetRawQuery() != "page=2" {
+				t.Errorf("Expected api_key to be removed from query, got '%s'", got.GetRawQuery())
+			}
+		})
+	}
+}
+
+func TestServer_ReloadAPIKeys_MissingStore(t *testing.T) {
+	fileReader := &MockFileReader{
+		ReadFileFunc: func(filename string) ([]byte, error) {
+			return nil, fmt.Errorf("open %s: %w", filename, fs.ErrNotExist)
+		},
+	}
+	server := NewServer(nil, fileReader, "/test/routes", zerolog.Nop(), WithAPIKeyStore(testKeyStorePath))
+
+	if err := server.ReloadAPIKeys(); err != nil {
+		t.Fatalf("Expected missing key store to load empty, got %v", err)
+	}
+	if store := server.apiKeys.Load(); store == nil || len(store.Keys) != 0 {
+		t.Errorf("Expected empty key store, got %v", store)
+	}
+}
+
+const testJWKSPath = "/test/jwks.json"
+
+type testSigner struct {
+	t   *testing.T
+	key *rsa.PrivateKey
+	kid string
+}
+
+func newTestSigner(t *testing.T, kid string) *testSigner {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	return &testSigner{t: t, key: key, kid: kid}
+}
+
+func (s *testSigner) jwks(others ...*testSigner) []byte {
+	set := jose.JSONWebKeySet{}
+	for _, signer := range append([]*testSigner{s}, others...) {
+		set.Keys = append(set.Keys, jose.JSONWebKey{Key: &signer.key.PublicKey, KeyID: signer.kid, Algorithm: "RS256", Use: "sig"})
+	}
+	data, err := json.Marshal(set)
+	if err != nil {
+		s.t.Fatalf("Failed to encode JWKS: %v", err)
+	}
+	return data
+}
+
+func (s *testSigner) token(claims map[string]any) string {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: s.key, KeyID: s.kid}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		s.t.Fatalf("Failed to create signer: %v", err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	if err != nil {
+		s.t.Fatalf("Failed to sign token: %v", err)
+	}
+	return token
+}
+
+func newJWTServer(t *testing.T, commClient CommunicationClient, jwks func() []byte) *Server {
+	t.Helper()
+
+	routes := routeFiles(map[string]string{
+		"orders.yml": `path: /orders
+method: GET
+action: orders
+auth: jwt
+jwt:
+  issuer: https://id.example.com
+  audience: orders-api
+  scopes: [orders:read]`,
+	})
+	readRoute := routes.ReadFileFunc
+	routes.ReadFileFunc = func(filename string) ([]byte, error) {
+		if filename == testJWKSPath {
+			return jwks(), nil
+		}
+		return readRoute(filename)
+	}
+
+	server := NewServer(commClient, routes, "/test/routes", zerolog.Nop(), WithJWKS(testJWKSPath))
+	if err := server.ReloadRoutes(); err != nil {
+		t.Fatalf("Failed to load routes: %v", err)
+	}
+	return server
+}
+
+func TestServer_JWTAuth(t *testing.T) {
+	signer := newTestSigner(t, "k1")
+	stranger := newTestSigner(t, "k1")
+	// Tokens without a kid are checked against every signing key
+	unnamed := &testSigner{t: t, key: signer.key}
+	unnamedStranger := &testSigner{t: t, key: stranger.key}
+
+	var got *pb.HttpRequest
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			got = req.GetHttp()
+			return &commpb.ExecuteResponse{}, nil
+		},
+	}
+	server := newJWTServer(t, commClient, func() []byte { return signer.jwks() })
+
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"iss":   "https://id.example.com",
+			"aud":   "orders-api",
+			"sub":   "user-42",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"scope": "orders:read profile",
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+
+	tests := []struct {
+		name      string
+		auth      string
+		code      int
+		challenge string
+	}{
+		{name: "valid token", auth: "Bearer " + signer.token(claims(nil)), code: http.StatusOK},
+		{name: "scp list", auth: "Bearer " + signer.token(claims(map[string]any{"scope": nil, "scp": []string{"orders:read"}})), code: http.StatusOK},
+		{name: "missing token", code: http.StatusUnauthorized, challenge: `Bearer realm="prism"`},
+		{name: "malformed token", auth: "Bearer not-a-token", code: http.StatusUnauthorized, challenge: `error="invalid_token"`},
+		{name: "wrong signer", auth: "Bearer " + stranger.token(claims(nil)), code: http.StatusUnauthorized, challenge: `error="invalid_token"`},
+		{name: "no kid", auth: "Bearer " + unnamed.token(claims(nil)), code: http.StatusOK},
+		{name: "no kid, wrong signer", auth: "Bearer " + unnamedStranger.token(claims(nil)), code: http.StatusUnauthorized, challenge: `error="invalid_token"`},
+		{name: "wrong issuer", auth: "Bearer " + signer.token(claims(map[string]any{"iss": "https://evil.example.com"})), code: http.StatusUnauthorized, challenge: `error="invalid_token"`},
+		{name: "wrong audience", auth: "Bearer " + signer.token(claims(map[string]any{"aud": "billing-api"})), code: http.StatusUnauthorized, challenge: `error="invalid_token"`},
+		{name: "expired", auth: "Bearer " + signer.token(claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})), code: http.StatusUnauthorized, challenge: `error="invalid_token"`},
+		{name: "no expiry", auth: "Bearer " + signer.token(claims(map[string]any{"exp": nil})), code: http.StatusUnauthorized, challenge: `error="invalid_token"`},
+		{name: "missing scope", auth: "Bearer " + signer.token(claims(map[string]any{"scope": "profile"})), code: http.StatusForbidden, challenge: `error="insufficient_scope"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			req := httptest.NewRequest("GET", "/orders", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+
+			server.handleAction(w, req)
+
+			if w.Code != tt.code {
+				t.Fatalf("Expected status code %d, got %d (%s)", tt.code, w.Code, w.Body.String())
+			}
+			if tt.code != http.StatusOK {
+				if got != nil {
+					t.Error("Expected action not to be called")
+				}
+				if challenge := w.Header().Get("WWW-Authenticate"); !strings.Contains(challenge, tt.challenge) {
+					t.Errorf("Expected WWW-Authenticate to contain '%s', got '%s'", tt.challenge, challenge)
+				}
+				return
+			}
+
+			identity := got.GetIdentity()
+			if identity.GetScheme() != AuthJWT || identity.GetSubject() != "user-42" {
+				t.Errorf("Unexpected identity: %v", identity)
+			}
+			var forwarded map[string]any
+			if err := json.Unmarshal(identity.GetClaims(), &forwarded); err != nil || forwarded["iss"] != "https://id.example.com" {
+				t.Errorf("Expected claims to be forwarded, got %s (%v)", identity.GetClaims(), err)
+			}
+		})
+	}
+}
+
+func TestServer_JWTAuth_KeyRotation(t *testing.T) {
+	oldSigner := newTestSigner(t, "old")
+	newSigner := newTestSigner(t, "new")
+
+	fetches := 0
+	jwks := oldSigner.jwks()
+	server := newJWTServer(t, &MockCommunicationClient{}, func() []byte {
+		fetches++
+		return jwks
+	})
+
+	now := time.Now()
+	server.jwks.now = func() time.Time { return now }
+
+	call := func(signer *testSigner) int {
+		req := httptest.NewRequest("GET", "/orders", nil)
+		req.Header.Set("Authorization", "Bearer "+signer.token(map[string]any{
+			"iss":   "https://id.example.com",
+			"aud":   "orders-api",
+			"exp":   now.Add(time.Hour).Unix(),
+			"scope": "orders:read",
+		}))
+		w := httptest.NewRecorder()
+		server.handleAction(w, req)
+		return w.Code
+	}
+
+	if code := call(oldSigner); code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, code)
+	}
+
+	// The provider rotates to a new key.
+	jwks = newSigner.jwks(oldSigner)
+	if code := call(newSigner); code != http.StatusUnauthorized {
+		t.Errorf("Expected unknown key to be rejected within the refresh limit, got %d", code)
+	}
+
+	now = now.Add(jwksMinRefreshInterval)
+	if code := call(newSigner); code != http.StatusOK {
+		t.Errorf("Expected rotated key to be accepted after refresh, got %d", code)
+	}
+	if code := call(oldSigner); code != http.StatusOK {
+		t.Errorf("Expected old key to stay valid, got %d", code)
+	}
+	if fetches != 2 {
+		t.Errorf("Expected 2 JWKS fetches, got %d", fetches)
+	}
+}
+
+func TestServer_JWTAuth_SlowRefresh(t *testing.T) {
+	signer := newTestSigner(t, "k1")
+
+	release := make(chan struct{})
+	defer close(release)
+	var slow atomic.Bool
+	server := newJWTServer(t, &MockCommunicationClient{}, func() []byte {
+		if slow.Load() {
+			<-release
+		}
+		return signer.jwks()
+	})
+
+	now := time.Now()
+	server.jwks.now = func() time.Time { return now }
+
+	token := signer.token(map[string]any{
+		"iss":   "https://id.example.com",
+		"aud":   "orders-api",
+		"exp":   now.Add(time.Hour).Unix(),
+		"scope": "orders:read",
+	})
+	call := func() int {
+		req := httptest.NewRequest("GET", "/orders", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		server.handleAction(w, req)
+		return w.Code
+	}
+
+	if code := call(); code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, code)
+	}
+
+	// The keys go stale and the provider hangs on the refresh
+	slow.Store(true)
+	now = now.Add(jwksRefreshInterval)
+	codes := make(chan int, 1)
+	go func() { codes <- call() }()
+
+	select {
+	case code := <-codes:
+		if code != http.StatusOK {
+			t.Errorf("Expected cached keys to be used during the refresh, got %d", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the request not to wait for the refresh")
+	}
+}
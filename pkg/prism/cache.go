@@ -0,0 +1,312 @@
+package prism
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"github.com/Ow1Dev/NoctiFunc/pkg/utils"
+)
+
+// DefaultCacheBytes is the memory the response cache may use unless
+// WithCacheBytes is given.
+const DefaultCacheBytes = 64 << 20
+
+// WithCacheBytes bounds the memory used by cached responses.
+func WithCacheBytes(n int64) Option {
+	return func(s *Server) {
+		s.cache.maxBytes = n
+	}
+}
+
+type cacheEntry struct {
+	key      string
+	route    string // path template of the route
+	path     string
+	status   int
+	header   http.Header
+	body     []byte
+	etag     string
+	storedAt time.Time
+	expires  time.Time
+}
+
+func (e *cacheEntry) size() int64 {
+	n := len(e.key) + len(e.route) + len(e.path) + len(e.body) + len(e.etag)
+	for key, values := range e.header {
+		n += len(key)
+		for _, v := range values {
+			n += len(v)
+		}
+	}
+	return int64(n)
+}
+
+// responseCache is an LRU of responses bounded by the total size of the
+// cached entries.
+type responseCache struct {
+	maxBytes int64
+	now      func() time.Time
+
+	mu    sync.Mutex
+	ll    *list.List // front is most recently used
+	items map[string]*list.Element
+	size  int64
+}
+
+func newResponseCache(maxBytes int64) *responseCache {
+	return &responseCache{
+		maxBytes: maxBytes,
+		now:      time.Now,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// get returns the unexpired entry stored under key, or nil.
+func (c *responseCache) get(key string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.remove(elem)
+		return nil
+	}
+	c.ll.MoveToFront(elem)
+	return entry
+}
+
+// add stores entry, evicting the least recently used entries to make room.
+// Entries larger than the whole cache are not stored.
+func (c *responseCache) add(entry *cacheEntry) {
+	size := entry.size()
+	if size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[entry.key]; ok {
+		c.remove(elem)
+	}
+	c.items[entry.key] = c.ll.PushFront(entry)
+	c.size += size
+
+	for c.size > c.maxBytes {
+		c.remove(c.ll.Back())
+	}
+}
+
+// purge removes the entries match returns true for and reports how many.
+func (c *responseCache) purge(match func(*cacheEntry) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	purged := 0
+	for elem := c.ll.Front(); elem != nil; {
+		next := elem.Next()
+		if match(elem.Value.(*cacheEntry)) {
+			c.remove(elem)
+			purged++
+		}
+		elem = next
+	}
+	return purged
+}
+
+func (c *responseCache) remove(elem *list.Element) {
+	entry := c.ll.Remove(elem).(*cacheEntry)
+	delete(c.items, entry.key)
+	c.size -= entry.size()
+}
+
+// serveCached answers a GET or HEAD request on a cached route, calling the
+// function only when no fresh response is cached. The client's Cache-Control
+// can demand a fresher response (max-age, no-cache) or keep the response out
+// of the cache entirely (no-store). Each target of a route with targets has
+// its own cached responses; action is the target serving r.
+func (s *Server) serveCached(w http.ResponseWriter, r *http.Request, rt *route, action string, params map[string]string, identity *pb.Identity) {
+	directives := cacheControl(r.Header.Get("Cache-Control"))
+	_, noStore := directives["no-store"]
+	_, noCache := directives["no-cache"]
+	key := cacheKey(r, rt, action, identity)
+
+	if !noStore && !noCache {
+		if entry := s.cache.get(key); entry != nil {
+			maxAge, err := strconv.Atoi(directives["max-age"])
+			if err != nil || s.cache.now().Sub(entry.storedAt) <= time.Duration(maxAge)*time.Second {
+				s.writeCached(w, r, entry, "HIT", identity != nil)
+				return
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.Ternary(rt.config.Timeout > 0, rt.config.Timeout, s.timeout))
+	defer cancel()
+
+	req := newExecuteRequest(r, rt.config, params, identity, nil)
+	req.Action = action
+	if err := rt.mapRequest(req); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	result, err := s.processAction(ctx, req)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	if err := rt.mapResponse(req, result); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	status := int(result.GetHttp().GetStatusCode())
+	header := responseHeader(result)
+	if (status != 0 && status != http.StatusOK) || !cacheable(header) {
+		s.writeResponse(w, r, result)
+		return
+	}
+
+	// Downstream caches must keep the variants apart as well
+	for _, name := range rt.config.Cache.Headers {
+		header.Add("Vary", http.CanonicalHeaderKey(name))
+	}
+
+	now := s.cache.now()
+	entry := &cacheEntry{
+		key:      key,
+		route:    rt.template.raw,
+		path:     r.URL.Path,
+		status:   http.StatusOK,
+		header:   header,
+		body:     result.GetResp(),
+		etag:     header.Get("ETag"),
+		storedAt: now,
+		expires:  now.Add(rt.config.Cache.TTL),
+	}
+	if entry.etag == "" {
+		sum := sha256.Sum256(entry.body)
+		entry.etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+	}
+	if !noStore {
+		s.cache.add(entry)
+	}
+	s.writeCached(w, r, entry, "MISS", identity != nil)
+}
+
+// writeCached sends entry, or 304 when the client already has it.
+func (s *Server) writeCached(w http.ResponseWriter, r *http.Request, entry *cacheEntry, xCache string, private bool) {
+	now := s.cache.now()
+	header := w.Header()
+	for key, values := range entry.header {
+		// Copied, since the entry is shared with concurrent responses; Vary
+		// keeps what was already set, such as Origin on CORS routes
+		if key == "Vary" {
+			header[key] = append(header[key], values...)
+		} else {
+			header[key] = slices.Clone(values)
+		}
+	}
+	header.Set("ETag", entry.etag)
+	header.Set("Age", strconv.Itoa(int(now.Sub(entry.storedAt).Seconds())))
+	header.Set("X-Cache", xCache)
+	if header.Get("Cache-Control") == "" {
+		maxAge := int(entry.expires.Sub(now).Seconds())
+		header.Set("Cache-Control", utils.Ternary(private, "private", "public")+", max-age="+strconv.Itoa(maxAge))
+	}
+
+	if etagMatches(r.Header.Get("If-None-Match"), entry.etag) {
+		header.Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header.Set("Content-Length", strconv.Itoa(len(entry.body)))
+	w.WriteHeader(entry.status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := w.Write(entry.body); err != nil {
+		s.log(r.Context()).Error().Err(err).Msg("Failed to write response")
+	}
+}
+
+// cacheKey identifies the response to r: the route and target, the path, the query
+// parameters and headers the route lists, and the caller on authenticated
+// routes so one caller never sees another's response.
+func cacheKey(r *http.Request, rt *route, action string, identity *pb.Identity) string {
+	var b strings.Builder
+	b.WriteString(rt.source)
+	b.WriteByte(0)
+	b.WriteString(action)
+	b.WriteByte(0)
+	b.WriteString(r.URL.Path)
+
+	query := r.URL.Query()
+	for _, name := range rt.config.Cache.Query {
+		fmt.Fprintf(&b, "\x00q:%s=%q", name, query[name])
+	}
+	for _, name := range rt.config.Cache.Headers {
+		fmt.Fprintf(&b, "\x00h:%s=%q", http.CanonicalHeaderKey(name), r.Header.Values(name))
+	}
+	if identity != nil {
+		fmt.Fprintf(&b, "\x00i:%s:%s:%s", identity.GetScheme(), identity.GetApiKeyId(), identity.GetSubject())
+	}
+	return b.String()
+}
+
+// cacheable reports whether a response with header may be cached.
+func cacheable(header http.Header) bool {
+	if len(header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+	directives := cacheControl(header.Get("Cache-Control"))
+	for _, d := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := directives[d]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheControl parses a Cache-Control header into its directives.
+func cacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name != "" {
+			directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+		}
+	}
+	return directives
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using
+// the weak comparison RFC 9110 requires for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	tags := strings.Split(ifNoneMatch, ",")
+	for i, tag := range tags {
+		tags[i] = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	}
+	return slices.Contains(tags, "*") || slices.Contains(tags, strings.TrimPrefix(etag, "W/"))
+}
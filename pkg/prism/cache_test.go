@@ -0,0 +1,271 @@
+package prism
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+)
+
+// newCacheServer serves a cached route on /items/{id} and counts invocations.
+func newCacheServer(t *testing.T, resp *commpb.ExecuteResponse, opts ...Option) (*Server, *fakeClock, *int) {
+	t.Helper()
+
+	calls := 0
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			calls++
+			return resp, nil
+		},
+	}
+	fileReader := routeFiles(map[string]string{
+		"items.yml": "path: /items/{id}\nmethod: GET\naction: items\ncache:\n  ttl: 1m\n  query: [page]\n  headers: [Accept-Language]",
+	})
+
+	server := newTestServer(t, commClient, fileReader, opts...)
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	server.cache.now = clock.now
+	return server, clock, &calls
+}
+
+func TestServer_Cache(t *testing.T) {
+	server, clock, calls := newCacheServer(t, &commpb.ExecuteResponse{Resp: []byte(`{"id": 1}`)})
+
+	tests := []struct {
+		name      string
+		target    string
+		header    http.Header
+		advance   time.Duration
+		wantCache string
+		wantAge   string
+		wantCalls int
+	}{
+		{name: "first request", target: "/items/1", wantCache: "MISS", wantAge: "0", wantCalls: 1},
+		{name: "cached", target: "/items/1", advance: 10 * time.Second, wantCache: "HIT", wantAge: "10", wantCalls: 1},
+		{name: "ignored query parameter", target: "/items/1?utm=x", wantCache: "HIT", wantAge: "10", wantCalls: 1},
+		{name: "keyed query parameter", target: "/items/1?page=2", wantCache: "MISS", wantAge: "0", wantCalls: 2},
+		{name: "keyed header", target: "/items/1", header: http.Header{"Accept-Language": {"de"}}, wantCache: "MISS", wantAge: "0", wantCalls: 3},
+		{name: "other path", target: "/items/2", wantCache: "MISS", wantAge: "0", wantCalls: 4},
+		{name: "client max-age", target: "/items/1", header: http.Header{"Cache-Control": {"max-age=5"}}, wantCache: "MISS", wantAge: "0", wantCalls: 5},
+		{name: "refreshed", target: "/items/1", advance: 3 * time.Second, wantCache: "HIT", wantAge: "3", wantCalls: 5},
+		{name: "client no-cache", target: "/items/1", header: http.Header{"Cache-Control": {"no-cache"}}, wantCache: "MISS", wantAge: "0", wantCalls: 6},
+		{name: "expired", target: "/items/1", advance: time.Minute, wantCache: "MISS", wantAge: "0", wantCalls: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.t = clock.t.Add(tt.advance)
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			w := httptest.NewRecorder()
+
+			server.handleAction(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+			}
+			if got := w.Header().Get("X-Cache"); got != tt.wantCache {
+				t.Errorf("Expected X-Cache %s, got %s", tt.wantCache, got)
+			}
+			if got := w.Header().Get("Age"); got != tt.wantAge {
+				t.Errorf("Expected Age %s, got %s", tt.wantAge, got)
+			}
+			if w.Header().Get("ETag") == "" {
+				t.Error("Expected an ETag")
+			}
+			if w.Body.String() != `{"id": 1}` {
+				t.Errorf("Expected cached body, got '%s'", w.Body.String())
+			}
+			if *calls != tt.wantCalls {
+				t.Errorf("Expected %d invocations, got %d", tt.wantCalls, *calls)
+			}
+		})
+	}
+}
+
+func TestServer_Cache_NotModified(t *testing.T) {
+	server, _, _ := newCacheServer(t, &commpb.ExecuteResponse{Resp: []byte(`{"id": 1}`)})
+
+	w := httptest.NewRecorder()
+	server.handleAction(w, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+	etag := w.Header().Get("ETag")
+
+	req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	req.Header.Set("If-None-Match", `W/"other", `+etag)
+	w = httptest.NewRecorder()
+	server.handleAction(w, req)
+
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("Expected status code %d, got %d", http.StatusNotModified, w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected empty body, got '%s'", w.Body.String())
+	}
+	if got := w.Header().Get("ETag"); got != etag {
+		t.Errorf("Expected ETag %s, got %s", etag, got)
+	}
+}
+
+func TestServer_Cache_HeadMiss(t *testing.T) {
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			if req.GetHttp().GetMethod() != http.MethodGet {
+				return &commpb.ExecuteResponse{}, nil
+			}
+			return &commpb.ExecuteResponse{Resp: []byte(`{"id": 1}`)}, nil
+		},
+	}
+	server := newTestServer(t, commClient, routeFiles(map[string]string{
+		"items.yml": "path: /items/{id}\nmethod: GET\naction: items\ncache:\n  ttl: 1m",
+	}))
+
+	w := httptest.NewRecorder()
+	server.handleAction(w, httptest.NewRequest(http.MethodHead, "/items/1", nil))
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected empty body for HEAD, got '%s'", w.Body.String())
+	}
+	if got := w.Header().Get("Content-Length"); got != "9" {
+		t.Errorf("Expected Content-Length 9, got %s", got)
+	}
+
+	w = httptest.NewRecorder()
+	server.handleAction(w, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+	if got := w.Header().Get("X-Cache"); got != "HIT" {
+		t.Errorf("Expected X-Cache HIT, got %s", got)
+	}
+	if w.Body.String() != `{"id": 1}` {
+		t.Errorf("Expected cached body, got '%s'", w.Body.String())
+	}
+}
+
+func TestServer_Cache_Uncacheable(t *testing.T) {
+	tests := []struct {
+		name string
+		resp *commpb.ExecuteResponse
+	}{
+		{
+			name: "function no-store",
+			resp: &commpb.ExecuteResponse{Http: &pb.HttpResponse{Headers: map[string]*pb.HeaderValues{
+				"Cache-Control": {Values: []string{"no-store"}},
+			}}},
+		},
+		{
+			name: "cookie",
+			resp: &commpb.ExecuteResponse{Http: &pb.HttpResponse{Headers: map[string]*pb.HeaderValues{
+				"Set-Cookie": {Values: []string{"session=1"}},
+			}}},
+		},
+		{
+			name: "error status",
+			resp: &commpb.ExecuteResponse{Http: &pb.HttpResponse{StatusCode: http.StatusNotFound}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _, calls := newCacheServer(t, tt.resp)
+
+			for range 2 {
+				server.handleAction(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/1", nil))
+			}
+
+			if *calls != 2 {
+				t.Errorf("Expected every request to invoke the function, got %d invocations", *calls)
+			}
+		})
+	}
+}
+
+func TestServer_PurgeCache(t *testing.T) {
+	server, _, calls := newCacheServer(t, &commpb.ExecuteResponse{Resp: []byte(`{}`)}, WithAdminToken("secret"))
+	handler, admin := server.Handler(), server.AdminHandler()
+
+	for _, target := range []string{"/items/1", "/items/2"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/_admin/cache?path=/items/1", nil)
+	w := httptest.NewRecorder()
+	admin.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d without token, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/_admin/cache?path=/items/1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code == http.StatusOK {
+		t.Error("Expected the public handler not to purge the cache")
+	}
+
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if got := strings.TrimSpace(w.Body.String()); got != `{"purged":1}` {
+		t.Errorf("Expected one purged response, got '%s'", got)
+	}
+
+	for _, target := range []string{"/items/1", "/items/2"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	if *calls != 3 {
+		t.Errorf("Expected only the purged path to be invoked again, got %d invocations", *calls)
+	}
+}
+
+func TestResponseCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newResponseCache(30)
+	expires := time.Now().Add(time.Hour)
+	entry := func(key string) *cacheEntry {
+		return &cacheEntry{key: key, body: []byte("0123456789"), expires: expires}
+	}
+
+	cache.add(entry("a"))
+	cache.add(entry("b"))
+	cache.get("a")
+	cache.add(entry("c"))
+
+	if cache.get("b") != nil {
+		t.Error("Expected least recently used entry to be evicted")
+	}
+	if cache.get("a") == nil || cache.get("c") == nil {
+		t.Error("Expected recently used entries to be kept")
+	}
+	if cache.size > 30 {
+		t.Errorf("Expected cache size within 30 bytes, got %d", cache.size)
+	}
+}
+
+func TestServer_Cache_Headers(t *testing.T) {
+	server, _, _ := newCacheServer(t, &commpb.ExecuteResponse{
+		Resp: []byte(`{}`),
+		Http: &pb.HttpResponse{Headers: map[string]*pb.HeaderValues{"X-Version": {Values: []string{"1"}}}},
+	})
+
+	for _, wantCache := range []string{"MISS", "HIT"} {
+		w := httptest.NewRecorder()
+		server.handleAction(w, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+
+		if got := w.Header().Get("X-Cache"); got != wantCache {
+			t.Fatalf("Expected X-Cache %s, got %s", wantCache, got)
+		}
+		if got := w.Header().Get("Vary"); got != "Accept-Language" {
+			t.Errorf("Expected Vary 'Accept-Language', got '%s'", got)
+		}
+		if got := w.Header().Get("X-Version"); got != "1" {
+			t.Errorf("Expected X-Version '1', got '%s'", got)
+		}
+		// Changing one response's headers must not change the cached entry
+		w.Header()["X-Version"][0] = "changed"
+	}
+}
@@ -1,26 +1,443 @@
 package prism
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type RouteConfig struct {
+	Path          string           `yaml:"path"`
+	Action        string           `yaml:"action"`
+	Method        string           `yaml:"method"`
+	Methods       []string         `yaml:"methods"`
+	MaxBodyBytes  int64            `yaml:"max_body_bytes"` // 0 uses the server default
+	Timeout       time.Duration    `yaml:"timeout"`        // 0 uses the server default
+	Auth          string           `yaml:"auth"`           // empty for public routes
+	JWT           *JWTConfig       `yaml:"jwt"`            // required when auth is jwt
+	RateLimit     *RateLimitConfig `yaml:"rate_limit"`
+	CORS          *CORSConfig      `yaml:"cors"`
+	Cache         *CacheConfig     `yaml:"cache"`          // caches GET responses
+	Invocation    string           `yaml:"invocation"`     // sync (default) or async
+	Stream        bool             `yaml:"stream"`         // relays output as the function produces it
+	WebSocket     *WebSocketConfig `yaml:"websocket"`      // serves the route as a WebSocket endpoint
+	Docs          *DocsConfig      `yaml:"docs"`           // describes the route in the OpenAPI document
+	RequestSchema *SchemaConfig    `yaml:"request_schema"` // validates JSON request bodies
+	Mapping       *MappingConfig   `yaml:"mapping"`        // reshapes the payload and the response
+	Targets       []TargetConfig   `yaml:"targets"`        // splits traffic between several actions
+	Sticky        *StickyConfig    `yaml:"sticky"`         // keeps a client on the same target
+}
+
+// TargetConfig is an action that receives a share of a route's requests.
+// Each request goes to a target with probability weight / total weight, so
+// weights of 95 and 5 send 5% of requests to the second target. A target
+// with weight 0 receives nothing until its weight is raised.
+type TargetConfig struct {
 	Action string `yaml:"action"`
-	Method string `yaml:"method"`
+	Weight int    `yaml:"weight"`
+}
+
+// StickyConfig assigns targets by the value of a request header or cookie
+// instead of at random, so a client that sends the same value is always
+// served by the same target while the weights stay the same. Requests
+// without the value are assigned at random.
+type StickyConfig struct {
+	Header string `yaml:"header"`
+	Cookie string `yaml:"cookie"`
+}
+
+// MappingConfig reshapes invocations with Go templates. Request builds the
+// function's payload from the path parameters, query, headers and body;
+// Response builds the body sent to the client from the function's output.
+// Either may be left empty to pass that side through unchanged.
+type MappingConfig struct {
+	Request             string `yaml:"request"`
+	RequestContentType  string `yaml:"request_content_type"` // application/json by default
+	Response            string `yaml:"response"`
+	ResponseContentType string `yaml:"response_content_type"` // application/json by default
+}
+
+// SchemaConfig is a JSON Schema given inline or read from File. Relative
+// files are resolved against the routes directory.
+type SchemaConfig struct {
+	Inline map[string]any `yaml:"inline"`
+	File   string         `yaml:"file"`
+}
+
+// DocsConfig describes a route in the OpenAPI document served at
+// /_openapi.json. Paths, methods, path parameters and auth are taken from
+// the route itself; everything here is optional.
+type DocsConfig struct {
+	Summary     string              `yaml:"summary"`
+	Description string              `yaml:"description"`
+	Tags        []string            `yaml:"tags"`
+	Parameters  []ParameterDoc      `yaml:"parameters"` // query and header parameters
+	Request     *BodyDoc            `yaml:"request"`
+	Responses   map[string]*BodyDoc `yaml:"responses"` // keyed by status code, such as "200" or "default"
+}
+
+// ParameterDoc documents a query or header parameter.
+type ParameterDoc struct {
+	Name        string         `yaml:"name"`
+	In          string         `yaml:"in"` // query or header
+	Description string         `yaml:"description"`
+	Required    bool           `yaml:"required"`
+	Schema      map[string]any `yaml:"schema"` // JSON Schema, a string by default
+}
+
+// BodyDoc documents a request or response body with an inline JSON Schema.
+type BodyDoc struct {
+	Description string         `yaml:"description"`
+	ContentType string         `yaml:"content_type"` // application/json by default
+	Schema      map[string]any `yaml:"schema"`
+}
+
+func (d *DocsConfig) validate() error {
+	for _, p := range d.Parameters {
+		if p.Name == "" {
+			return fmt.Errorf("docs parameters need a name")
+		}
+		if p.In != "query" && p.In != "header" {
+			return fmt.Errorf("docs parameter %s: in must be query or header", p.Name)
+		}
+		if err := validateSchema(p.Schema); err != nil {
+			return fmt.Errorf("docs parameter %s: %w", p.Name, err)
+		}
+	}
+	if d.Request != nil {
+		if err := validateSchema(d.Request.Schema); err != nil {
+			return fmt.Errorf("docs request: %w", err)
+		}
+	}
+	for code, resp := range d.Responses {
+		if n, err := strconv.Atoi(code); code != "default" && (err != nil || n < 100 || n > 599) {
+			return fmt.Errorf("docs response %s: must be a status code or default", code)
+		}
+		if resp == nil {
+			continue
+		}
+		if err := validateSchema(resp.Schema); err != nil {
+			return fmt.Errorf("docs response %s: %w", code, err)
+		}
+	}
+	return nil
+}
+
+// validateSchema checks that a schema written in YAML can be served as JSON.
+func validateSchema(schema map[string]any) error {
+	if _, err := json.Marshal(schema); err != nil {
+		return fmt.Errorf("schema cannot be encoded as JSON: %w", err)
+	}
+	return nil
+}
+
+// WebSocketConfig makes a route accept WebSocket connections. The route's
+// action is invoked for every message a client sends; Connect and Disconnect
+// are optional actions invoked when a client connects and once it is gone.
+// A Connect action that fails or returns a non-2xx status rejects the client.
+type WebSocketConfig struct {
+	Connect    string `yaml:"connect"`
+	Disconnect string `yaml:"disconnect"`
+}
+
+// JWTConfig lists what a bearer token must contain to call a jwt route.
+type JWTConfig struct {
+	Issuer   string   `yaml:"issuer"`
+	Audience string   `yaml:"audience"`
+	Scopes   []string `yaml:"scopes"`
+}
+
+// CORSConfig lets browsers on other origins call a route. Origins are exact
+// ("https://app.example.com"), wildcard subdomains ("https://*.example.com")
+// or "*" for any origin.
+type CORSConfig struct {
+	AllowOrigins     []string      `yaml:"allow_origins"`
+	AllowMethods     []string      `yaml:"allow_methods"` // empty uses the route's methods
+	AllowHeaders     []string      `yaml:"allow_headers"` // "*" allows any header
+	ExposeHeaders    []string      `yaml:"expose_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age"` // how long browsers may cache a preflight
+}
+
+func (c *CORSConfig) validate() error {
+	if len(c.AllowOrigins) == 0 {
+		return fmt.Errorf("cors.allow_origins is required")
+	}
+	for _, origin := range c.AllowOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				return fmt.Errorf("cors.allow_origins cannot be * with allow_credentials")
+			}
+			continue
+		}
+		scheme, host, ok := strings.Cut(origin, "://")
+		if !ok || scheme == "" || host == "" || strings.Contains(host, "/") {
+			return fmt.Errorf("invalid cors origin: %s", origin)
+		}
+		if strings.Contains(host, "*") && (!strings.HasPrefix(host, "*.") || strings.Count(host, "*") > 1) {
+			return fmt.Errorf("invalid cors origin %s: wildcards must be a leading *.", origin)
+		}
+	}
+	for _, method := range c.AllowMethods {
+		switch method {
+		case "GET", "HEAD", "POST", "PUT", "DELETE", "PATCH":
+		default:
+			return fmt.Errorf("unsupported cors method: %s", method)
+		}
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("cors.max_age must not be negative")
+	}
+	return nil
+}
+
+// CacheConfig lets Prism serve GET responses from memory for TTL. Cached
+// responses are keyed by route and path, plus the listed query parameters and
+// request headers; other query parameters and headers do not affect the key.
+type CacheConfig struct {
+	TTL     time.Duration `yaml:"ttl"`
+	Query   []string      `yaml:"query"`
+	Headers []string      `yaml:"headers"`
+}
+
+// RateLimitConfig sets token-bucket limits on a route. The route limit is
+// shared by all callers, the ip limit applies to each client address and the
+// api_key limit to each API key. Unset limits do not apply.
+type RateLimitConfig struct {
+	Route  *Limit `yaml:"route"`
+	IP     *Limit `yaml:"ip"`
+	APIKey *Limit `yaml:"api_key"` // requires auth: api_key
+}
+
+// Limit is a token bucket that refills Requests tokens every Per and holds at
+// most Burst tokens. Each request takes one token.
+type Limit struct {
+	Requests int           `yaml:"requests"`
+	Per      time.Duration `yaml:"per"`   // 0 means one second
+	Burst    int           `yaml:"burst"` // 0 means Requests
+}
+
+func (l *Limit) validate() error {
+	if l.Requests <= 0 {
+		return fmt.Errorf("requests must be positive")
+	}
+	if l.Per < 0 {
+		return fmt.Errorf("per must not be negative")
+	}
+	if l.Burst < 0 {
+		return fmt.Errorf("burst must not be negative")
+	}
+	return nil
+}
+
+func (rc *RateLimitConfig) validate(auth string) error {
+	for _, l := range []struct {
+		name  string
+		limit *Limit
+	}{{"route", rc.Route}, {"ip", rc.IP}, {"api_key", rc.APIKey}} {
+		if l.limit == nil {
+			continue
+		}
+		if err := l.limit.validate(); err != nil {
+			return fmt.Errorf("rate_limit.%s: %w", l.name, err)
+		}
+	}
+	if rc.APIKey != nil && auth != AuthAPIKey {
+		return fmt.Errorf("rate_limit.api_key requires auth: %s", AuthAPIKey)
+	}
+	return nil
+}
+
+// reservedPaths are served by Prism itself, so routes on them would never be reached.
+var reservedPaths = []string{"/healthz", "/readyz", "/_openapi.json"}
+
+// reservedPath reports whether path is served by Prism itself.
+func reservedPath(path string) bool {
+	return slices.Contains(reservedPaths, path) || strings.HasPrefix(path, "/_invocations/")
 }
 
 func (rc *RouteConfig) Validate() error {
 	if rc.Action == "" {
 		return fmt.Errorf("action is required")
 	}
-	switch rc.Method {
-	case "GET", "POST", "PUT", "DELETE", "PATCH":
-		return nil
+	if rc.Path != "" {
+		if _, err := parsePathTemplate(rc.Path); err != nil {
+			return fmt.Errorf("invalid path: %w", err)
+		}
+	}
+	if reservedPath(rc.RoutePath()) {
+		return fmt.Errorf("path %s is reserved by Prism", rc.RoutePath())
+	}
+	if rc.MaxBodyBytes < 0 {
+		return fmt.Errorf("max_body_bytes must not be negative")
+	}
+	if rc.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	switch rc.Auth {
+	case "", AuthAPIKey:
+		if rc.JWT != nil {
+			return fmt.Errorf("jwt requires auth: %s", AuthJWT)
+		}
+	case AuthJWT:
+		if rc.JWT == nil || rc.JWT.Issuer == "" || rc.JWT.Audience == "" {
+			return fmt.Errorf("auth %s requires jwt.issuer and jwt.audience", AuthJWT)
+		}
 	default:
-		return fmt.Errorf("unsupported method: %s", rc.Method)
+		return fmt.Errorf("unsupported auth: %s", rc.Auth)
+	}
+	if rc.RateLimit != nil {
+		if err := rc.RateLimit.validate(rc.Auth); err != nil {
+			return err
+		}
+	}
+	if rc.CORS != nil {
+		if err := rc.CORS.validate(); err != nil {
+			return err
+		}
+	}
+	switch rc.Invocation {
+	case "", InvocationSync, InvocationAsync:
+	default:
+		return fmt.Errorf("unsupported invocation: %s", rc.Invocation)
+	}
+	if rc.Stream && rc.Cache != nil {
+		return fmt.Errorf("stream and cache cannot both be set")
+	}
+	if rc.WebSocket != nil && (rc.Stream || rc.Cache != nil || rc.Invocation == InvocationAsync) {
+		return fmt.Errorf("websocket cannot be combined with stream, cache or async invocation")
+	}
+	if rc.Docs != nil {
+		if err := rc.Docs.validate(); err != nil {
+			return err
+		}
+	}
+	if rc.RequestSchema != nil {
+		if (rc.RequestSchema.Inline == nil) == (rc.RequestSchema.File == "") {
+			return fmt.Errorf("request_schema needs exactly one of inline and file")
+		}
+		if err := validateSchema(rc.RequestSchema.Inline); err != nil {
+			return fmt.Errorf("request_schema: %w", err)
+		}
+		if rc.WebSocket != nil {
+			return fmt.Errorf("request_schema cannot be combined with websocket")
+		}
+	}
+	if rc.Mapping != nil {
+		if _, err := newMapping(rc.Mapping); err != nil {
+			return err
+		}
+		if rc.WebSocket != nil {
+			return fmt.Errorf("mapping cannot be combined with websocket")
+		}
+		if rc.Mapping.Response != "" && (rc.Stream || rc.Invocation == InvocationAsync) {
+			return fmt.Errorf("mapping.response cannot be combined with stream or async invocation")
+		}
+	}
+	if len(rc.Targets) > 0 {
+		if err := validateTargets(rc.Targets); err != nil {
+			return err
+		}
+		if rc.WebSocket != nil {
+			return fmt.Errorf("targets cannot be combined with websocket")
+		}
+	}
+	if rc.Sticky != nil {
+		if len(rc.Targets) == 0 {
+			return fmt.Errorf("sticky requires targets")
+		}
+		if (rc.Sticky.Header == "") == (rc.Sticky.Cookie == "") {
+			return fmt.Errorf("sticky needs exactly one of header and cookie")
+		}
+	}
+	if rc.Cache != nil && rc.Cache.TTL <= 0 {
+		return fmt.Errorf("cache.ttl must be positive")
+	}
+	if rc.Method != "" && len(rc.Methods) > 0 {
+		return fmt.Errorf("method and methods cannot both be set")
+	}
+
+	methods := rc.AllowedMethods()
+	if len(methods) == 0 {
+		return fmt.Errorf("at least one method is required")
+	}
+
+	if rc.Cache != nil && !slices.Contains(methods, http.MethodGet) {
+		return fmt.Errorf("cache requires method GET")
+	}
+	if rc.WebSocket != nil && !slices.Equal(methods, []string{http.MethodGet}) {
+		return fmt.Errorf("websocket requires method GET only")
+	}
+
+	seen := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		switch method {
+		case "GET", "POST", "PUT", "DELETE", "PATCH":
+		default:
+			return fmt.Errorf("unsupported method: %s", method)
+		}
+		if seen[method] {
+			return fmt.Errorf("duplicate method: %s", method)
+		}
+		seen[method] = true
+	}
+	return nil
+}
+
+func validateTargets(targets []TargetConfig) error {
+	total := 0
+	seen := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		if t.Action == "" {
+			return fmt.Errorf("targets need an action")
+		}
+		if seen[t.Action] {
+			return fmt.Errorf("duplicate target: %s", t.Action)
+		}
+		seen[t.Action] = true
+		if t.Weight < 0 {
+			return fmt.Errorf("target %s: weight must not be negative", t.Action)
+		}
+		total += t.Weight
+	}
+	if total == 0 {
+		return fmt.Errorf("targets need a positive total weight")
+	}
+	return nil
+}
+
+// AllowedMethods returns the HTTP methods the route is configured for.
+func (rc *RouteConfig) AllowedMethods() []string {
+	if len(rc.Methods) > 0 {
+		return rc.Methods
+	}
+	if rc.Method != "" {
+		return []string{rc.Method}
+	}
+	return nil
+}
+
+// Allows reports whether the route serves method. HEAD is served by every GET route.
+func (rc *RouteConfig) Allows(method string) bool {
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	return slices.Contains(rc.AllowedMethods(), method)
+}
+
+// RoutePath returns the path template the route is served on. Routes without
+// an explicit path fall back to the action name, so action "a.b" is served on /a/b.
+func (rc *RouteConfig) RoutePath() string {
+	if rc.Path != "" {
+		return rc.Path
 	}
+	return "/" + strings.ReplaceAll(rc.Action, ".", "/")
 }
 
 func loadFromYaml(data []byte) (*RouteConfig, error) {
@@ -1,6 +1,9 @@
 package prism
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestRouteConfig_Validate(t *testing.T) {
 	tests := []struct {
@@ -24,6 +27,363 @@ func TestRouteConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "reserved path",
+			config: RouteConfig{
+				Path:   "/healthz",
+				Action: "test-action",
+				Method: "POST",
+			},
+			wantErr: true,
+		},
+		{
+			name: "reserved invocation path",
+			config: RouteConfig{
+				Path:   "/_invocations/{id}",
+				Action: "test-action",
+				Method: "GET",
+			},
+			wantErr: true,
+		},
+		{
+			name: "reserved path from action",
+			config: RouteConfig{
+				Action: "readyz",
+				Method: "GET",
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid path template",
+			config: RouteConfig{
+				Path:   "/users/{id}",
+				Action: "test-action",
+				Method: "GET",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid path template",
+			config: RouteConfig{
+				Path:   "users/{id",
+				Action: "test-action",
+				Method: "GET",
+			},
+			wantErr: true,
+		},
+		{
+			name: "multiple methods",
+			config: RouteConfig{
+				Action:  "test-action",
+				Methods: []string{"GET", "POST"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "no method",
+			config: RouteConfig{
+				Action: "test-action",
+			},
+			wantErr: true,
+		},
+		{
+			name: "method and methods",
+			config: RouteConfig{
+				Action:  "test-action",
+				Method:  "GET",
+				Methods: []string{"POST"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate methods",
+			config: RouteConfig{
+				Action:  "test-action",
+				Methods: []string{"GET", "GET"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported method in methods",
+			config: RouteConfig{
+				Action:  "test-action",
+				Methods: []string{"GET", "ERROR"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative max body bytes",
+			config: RouteConfig{
+				Action:       "test-action",
+				Method:       "POST",
+				MaxBodyBytes: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative timeout",
+			config: RouteConfig{
+				Action:  "test-action",
+				Method:  "GET",
+				Timeout: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "jwt auth",
+			config: RouteConfig{
+				Action: "test-action",
+				Method: "GET",
+				Auth:   "jwt",
+				JWT:    &JWTConfig{Issuer: "https://id.example.com", Audience: "api"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "jwt auth without audience",
+			config: RouteConfig{
+				Action: "test-action",
+				Method: "GET",
+				Auth:   "jwt",
+				JWT:    &JWTConfig{Issuer: "https://id.example.com"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "jwt settings without jwt auth",
+			config: RouteConfig{
+				Action: "test-action",
+				Method: "GET",
+				JWT:    &JWTConfig{Issuer: "https://id.example.com", Audience: "api"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported auth",
+			config: RouteConfig{
+				Action: "test-action",
+				Method: "GET",
+				Auth:   "basic",
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid rate limit",
+			config: RouteConfig{
+				Action: "test-action",
+				Method: "GET",
+				RateLimit: &RateLimitConfig{
+					Route: &Limit{Requests: 100},
+					IP:    &Limit{Requests: 10, Per: time.Minute, Burst: 20},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "rate limit without requests",
+			config: RouteConfig{
+				Action:    "test-action",
+				Method:    "GET",
+				RateLimit: &RateLimitConfig{IP: &Limit{Per: time.Second}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "api key rate limit without api key auth",
+			config: RouteConfig{
+				Action:    "test-action",
+				Method:    "GET",
+				RateLimit: &RateLimitConfig{APIKey: &Limit{Requests: 10}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid cors",
+			config: RouteConfig{
+				Action: "test-action",
+				Method: "GET",
+				CORS: &CORSConfig{
+					AllowOrigins:     []string{"https://app.example.com", "https://*.example.com"},
+					AllowCredentials: true,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "cors any origin with credentials",
+			config: RouteConfig{
+				Action: "test-action",
+				Method: "GET",
+				CORS:   &CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true},
+			},
+			wantErr: true,
+		},
+		{
+			name: "cors wildcard inside origin",
+			config: RouteConfig{
+				Action: "test-action",
+				Method: "GET",
+				CORS:   &CORSConfig{AllowOrigins: []string{"https://app.*.com"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "cache without ttl",
+			config: RouteConfig{
+				Action: "test-action",
+				Method: "GET",
+				Cache:  &CacheConfig{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "cache on route without GET",
+			config: RouteConfig{
+				Action: "test-action",
+				Method: "POST",
+				Cache:  &CacheConfig{TTL: time.Minute},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported invocation",
+			config: RouteConfig{
+				Action:     "test-action",
+				Method:     "POST",
+				Invocation: "later",
+			},
+			wantErr: true,
+		},
+		{
+			name: "stream with cache",
+			config: RouteConfig{
+				Action: "test-action",
+				Method: "GET",
+				Stream: true,
+				Cache:  &CacheConfig{TTL: time.Minute},
+			},
+			wantErr: true,
+		},
+		{
+			name: "websocket",
+			config: RouteConfig{
+				Action:    "chat.message",
+				Method:    "GET",
+				WebSocket: &WebSocketConfig{Connect: "chat.connect", Disconnect: "chat.disconnect"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "websocket with other methods",
+			config: RouteConfig{
+				Action:    "chat.message",
+				Methods:   []string{"GET", "POST"},
+				WebSocket: &WebSocketConfig{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "websocket with stream",
+			config: RouteConfig{
+				Action:    "chat.message",
+				Method:    "GET",
+				Stream:    true,
+				WebSocket: &WebSocketConfig{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "docs with invalid response code",
+			config: RouteConfig{
+				Action: "test-action",
+				Method: "GET",
+				Docs:   &DocsConfig{Responses: map[string]*BodyDoc{"ok": {}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "docs with path parameter",
+			config: RouteConfig{
+				Action: "test-action",
+				Method: "GET",
+				Docs:   &DocsConfig{Parameters: []ParameterDoc{{Name: "id", In: "path"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "request schema with inline and file",
+			config: RouteConfig{
+				Action:        "test-action",
+				Method:        "POST",
+				RequestSchema: &SchemaConfig{Inline: map[string]any{"type": "object"}, File: "order.json"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "mapping",
+			config: RouteConfig{
+				Action:  "test-action",
+				Method:  "POST",
+				Mapping: &MappingConfig{Request: `{"id":{{json .Params.id}}}`, Response: `{{.RawBody}}`},
+			},
+			wantErr: false,
+		},
+		{
+			name: "mapping with invalid template",
+			config: RouteConfig{
+				Action:  "test-action",
+				Method:  "POST",
+				Mapping: &MappingConfig{Request: `{{.Body`},
+			},
+			wantErr: true,
+		},
+		{
+			name: "response mapping with stream",
+			config: RouteConfig{
+				Action:  "test-action",
+				Method:  "GET",
+				Stream:  true,
+				Mapping: &MappingConfig{Response: `{{.RawBody}}`},
+			},
+			wantErr: true,
+		},
+		{
+			name: "targets with sticky cookie",
+			config: RouteConfig{
+				Action:  "test-action",
+				Method:  "GET",
+				Targets: []TargetConfig{{Action: "v1", Weight: 95}, {Action: "v2", Weight: 5}},
+				Sticky:  &StickyConfig{Cookie: "session"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "targets without weight",
+			config: RouteConfig{
+				Action:  "test-action",
+				Method:  "GET",
+				Targets: []TargetConfig{{Action: "v1"}, {Action: "v2"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate targets",
+			config: RouteConfig{
+				Action:  "test-action",
+				Method:  "GET",
+				Targets: []TargetConfig{{Action: "v1", Weight: 1}, {Action: "v1", Weight: 1}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "sticky without targets",
+			config: RouteConfig{
+				Action: "test-action",
+				Method: "GET",
+				Sticky: &StickyConfig{Header: "X-User"},
+			},
+			wantErr: true,
+		},
 		{
 			name: "unsupported method",
 			config: RouteConfig{
@@ -43,3 +403,38 @@ func TestRouteConfig_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestRouteConfig_RoutePath(t *testing.T) {
+	tests := []struct {
+		config   RouteConfig
+		expected string
+	}{
+		{RouteConfig{Action: "test.action"}, "/test/action"},
+		{RouteConfig{Action: "api.v1.users"}, "/api/v1/users"},
+		{RouteConfig{Action: "users.get", Path: "/users/{id}"}, "/users/{id}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := tt.config.RoutePath(); got != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRouteConfig_Allows(t *testing.T) {
+	cfg := RouteConfig{Action: "test-action", Methods: []string{"GET", "POST"}}
+
+	for method, expected := range map[string]bool{
+		"GET":    true,
+		"HEAD":   true,
+		"POST":   true,
+		"PUT":    false,
+		"DELETE": false,
+	} {
+		if got := cfg.Allows(method); got != expected {
+			t.Errorf("Allows(%s) = %v, want %v", method, got, expected)
+		}
+	}
+}
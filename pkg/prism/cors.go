@@ -0,0 +1,144 @@
+package prism
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// isPreflight reports whether r is a CORS preflight request.
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// handlePreflight answers a CORS preflight for the route serving the
+// requested method. Paths whose route has no cors block get the plain
+// OPTIONS response, which browsers treat as a refusal.
+func (s *Server) handlePreflight(w http.ResponseWriter, routes *routeTable, r *http.Request) {
+	method := r.Header.Get("Access-Control-Request-Method")
+	rt, _, err := routes.lookup(method, r.URL.Path)
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	cfg := rt.config.CORS
+	if cfg == nil {
+		s.handleOptions(w, r, routes)
+		return
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+
+	origin := r.Header.Get("Origin")
+	if !cfg.allowsOrigin(origin) {
+		s.handleError(w, r, &HTTPError{Code: http.StatusForbidden, Message: "Origin " + origin + " is not allowed"})
+		return
+	}
+
+	methods := cfg.AllowMethods
+	if len(methods) == 0 {
+		methods = rt.config.AllowedMethods()
+	}
+	if !slices.Contains(methods, method) {
+		s.handleError(w, r, &HTTPError{Code: http.StatusForbidden, Message: "Method " + method + " is not allowed by CORS"})
+		return
+	}
+
+	requested := requestedHeaders(r)
+	allowHeaders := strings.Join(cfg.AllowHeaders, ", ")
+	if slices.Contains(cfg.AllowHeaders, "*") {
+		// Echo the requested headers, as browsers ignore a * wildcard on
+		// credentialed requests.
+		allowHeaders = strings.Join(requested, ", ")
+	} else {
+		for _, name := range requested {
+			if !slices.ContainsFunc(cfg.AllowHeaders, func(allowed string) bool { return strings.EqualFold(allowed, name) }) {
+				s.handleError(w, r, &HTTPError{Code: http.StatusForbidden, Message: "Header " + name + " is not allowed by CORS"})
+				return
+			}
+		}
+	}
+
+	cfg.setOriginHeaders(header, origin)
+	header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if allowHeaders != "" {
+		header.Set("Access-Control-Allow-Headers", allowHeaders)
+	}
+	if cfg.MaxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyCORS adds the CORS headers of the route to the response of an actual
+// request. They are set before the function runs so error responses carry
+// them too and browsers can read them.
+func applyCORS(w http.ResponseWriter, r *http.Request, cfg *CORSConfig) {
+	if cfg == nil {
+		return
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Origin")
+
+	origin := r.Header.Get("Origin")
+	if origin == "" || !cfg.allowsOrigin(origin) {
+		return
+	}
+	cfg.setOriginHeaders(header, origin)
+	if len(cfg.ExposeHeaders) > 0 {
+		header.Set("Access-Control-Expose-Headers", strings.Join(cfg.ExposeHeaders, ", "))
+	}
+}
+
+func (c *CORSConfig) setOriginHeaders(header http.Header, origin string) {
+	if slices.Contains(c.AllowOrigins, "*") {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// allowsOrigin reports whether origin matches one of the allowed origins.
+// A wildcard origin such as https://*.example.com matches any subdomain of
+// example.com on that scheme, but not example.com itself.
+func (c *CORSConfig) allowsOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range c.AllowOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		prefix, suffix, ok := strings.Cut(allowed, "*")
+		if !ok || len(origin) <= len(prefix)+len(suffix) {
+			continue
+		}
+		if strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestedHeaders returns the headers named in Access-Control-Request-Headers.
+func requestedHeaders(r *http.Request) []string {
+	var names []string
+	for _, value := range r.Header.Values("Access-Control-Request-Headers") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
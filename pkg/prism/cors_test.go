@@ -0,0 +1,179 @@
+package prism
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newCORSServer(t *testing.T) *Server {
+	t.Helper()
+
+	return newTestServer(t, &MockCommunicationClient{}, routeFiles(map[string]string{
+		"orders.yml": `path: /orders
+methods: [GET, POST]
+action: orders
+cors:
+  allow_origins: ["https://app.example.com", "https://*.example.org"]
+  allow_headers: [Content-Type, Authorization]
+  expose_headers: [X-Total-Count]
+  allow_credentials: true
+  max_age: 10m`,
+		"public.yml": "path: /public\nmethod: GET\naction: public\ncors:\n  allow_origins: [\"*\"]\n  allow_headers: [\"*\"]",
+		"plain.yml":  "path: /plain\nmethod: GET\naction: plain",
+	}))
+}
+
+func TestServer_CORSPreflight(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		origin       string
+		method       string
+		headers      string
+		wantStatus   int
+		wantOrigin   string
+		wantMethods  string
+		wantHeaders  string
+		wantMaxAge   string
+		wantCredsSet bool
+	}{
+		{
+			name:         "exact origin",
+			path:         "/orders",
+			origin:       "https://app.example.com",
+			method:       "POST",
+			headers:      "content-type, authorization",
+			wantStatus:   http.StatusNoContent,
+			wantOrigin:   "https://app.example.com",
+			wantMethods:  "GET, POST",
+			wantHeaders:  "Content-Type, Authorization",
+			wantMaxAge:   "600",
+			wantCredsSet: true,
+		},
+		{
+			name:         "wildcard subdomain",
+			path:         "/orders",
+			origin:       "https://shop.eu.example.org",
+			method:       "GET",
+			wantStatus:   http.StatusNoContent,
+			wantOrigin:   "https://shop.eu.example.org",
+			wantMethods:  "GET, POST",
+			wantHeaders:  "Content-Type, Authorization",
+			wantMaxAge:   "600",
+			wantCredsSet: true,
+		},
+		{
+			name:       "wildcard does not match the bare domain",
+			path:       "/orders",
+			origin:     "https://example.org",
+			method:     "GET",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "disallowed origin",
+			path:       "/orders",
+			origin:     "https://evil.com",
+			method:     "POST",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "disallowed header",
+			path:       "/orders",
+			origin:     "https://app.example.com",
+			method:     "POST",
+			headers:    "X-Custom",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "method without route",
+			path:       "/orders",
+			origin:     "https://app.example.com",
+			method:     "DELETE",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:        "any origin and header",
+			path:        "/public",
+			origin:      "https://anywhere.test",
+			method:      "GET",
+			headers:     "X-Custom",
+			wantStatus:  http.StatusNoContent,
+			wantOrigin:  "*",
+			wantMethods: "GET",
+			wantHeaders: "X-Custom",
+		},
+		{
+			name:       "route without cors",
+			path:       "/plain",
+			origin:     "https://app.example.com",
+			method:     "GET",
+			wantStatus: http.StatusNoContent,
+		},
+	}
+
+	server := newCORSServer(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, tt.path, nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			if tt.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+			w := httptest.NewRecorder()
+
+			server.handleAction(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status code %d, got %d", tt.wantStatus, w.Code)
+			}
+			for header, want := range map[string]string{
+				"Access-Control-Allow-Origin":  tt.wantOrigin,
+				"Access-Control-Allow-Methods": tt.wantMethods,
+				"Access-Control-Allow-Headers": tt.wantHeaders,
+				"Access-Control-Max-Age":       tt.wantMaxAge,
+			} {
+				if got := w.Header().Get(header); got != want {
+					t.Errorf("Expected %s '%s', got '%s'", header, want, got)
+				}
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials") == "true"; got != tt.wantCredsSet {
+				t.Errorf("Expected Access-Control-Allow-Credentials set %v, got %v", tt.wantCredsSet, got)
+			}
+		})
+	}
+}
+
+func TestServer_CORSActualRequest(t *testing.T) {
+	server := newCORSServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+
+	server.handleAction(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Expected Access-Control-Allow-Origin 'https://app.example.com', got '%s'", got)
+	}
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got != "X-Total-Count" {
+		t.Errorf("Expected Access-Control-Expose-Headers 'X-Total-Count', got '%s'", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("Expected Vary 'Origin', got '%s'", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("Origin", "https://evil.com")
+	w = httptest.NewRecorder()
+
+	server.handleAction(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected no Access-Control-Allow-Origin for a disallowed origin, got '%s'", got)
+	}
+}
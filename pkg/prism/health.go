@@ -0,0 +1,59 @@
+package prism
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Ow1Dev/NoctiFunc/pkg/utils"
+)
+
+// readinessTimeout bounds the igniterelay check made for /readyz.
+const readinessTimeout = 2 * time.Second
+
+// Readiness is the body of /readyz. Checks maps each check to "ok" or
+// "unavailable"; the reason a check failed is only logged, since /readyz is
+// served to clients.
+type Readiness struct {
+	Status string            `json:"status"` // ready or not_ready
+	Checks map[string]string `json:"checks"`
+}
+
+// handleHealthz reports that the process is up and serving HTTP.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, r, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadyz reports whether requests can be served: the routes directory
+// was read on the last reload and igniterelay is serving. Not ready is
+// answered with 503.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ready := s.Readiness(r.Context())
+	s.writeJSON(w, r, utils.Ternary(ready.Status == "ready", http.StatusOK, http.StatusServiceUnavailable), ready)
+}
+
+// Readiness runs the readiness checks.
+func (s *Server) Readiness(ctx context.Context) *Readiness {
+	ready := &Readiness{Status: "ready", Checks: make(map[string]string, 2)}
+	fail := func(check string) {
+		ready.Status = "not_ready"
+		ready.Checks[check] = "unavailable"
+	}
+
+	if s.routesOK.Load() {
+		ready.Checks["routes"] = "ok"
+	} else {
+		s.log(ctx).Warn().Msgf("Not ready: routes directory %s could not be read", s.routesPath)
+		fail("routes")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+	if err := s.commClient.Health(ctx); err != nil {
+		s.log(ctx).Warn().Err(err).Msg("Not ready: igniterelay is unavailable")
+		fail("igniterelay")
+	} else {
+		ready.Checks["igniterelay"] = "ok"
+	}
+	return ready
+}
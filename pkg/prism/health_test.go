@@ -0,0 +1,75 @@
+package prism
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestServer_Healthz(t *testing.T) {
+	server := newTestServer(t, &MockCommunicationClient{}, &MockFileReader{})
+
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestServer_Readyz(t *testing.T) {
+	tests := []struct {
+		name       string
+		listErr    error
+		relayErr   error
+		wantStatus int
+		wantFailed []string
+	}{
+		{name: "ready", wantStatus: http.StatusOK},
+		{name: "igniterelay unreachable", relayErr: errors.New("remote service is NOT_SERVING"), wantStatus: http.StatusServiceUnavailable, wantFailed: []string{"igniterelay"}},
+		{name: "routes not loaded", listErr: errors.New("no such directory"), wantStatus: http.StatusServiceUnavailable, wantFailed: []string{"routes"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commClient := &MockCommunicationClient{
+				HealthFunc: func(ctx context.Context) error { return tt.relayErr },
+			}
+			fileReader := &MockFileReader{
+				ListFilesFunc: func(dirname string) ([]string, error) { return nil, tt.listErr },
+			}
+			server := NewServer(commClient, fileReader, "/test/routes", zerolog.Nop())
+			_ = server.ReloadRoutes()
+
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+
+			var ready Readiness
+			if err := json.Unmarshal(w.Body.Bytes(), &ready); err != nil {
+				t.Fatalf("Failed to decode readiness: %v", err)
+			}
+			failed := 0
+			for check, result := range ready.Checks {
+				if result != "ok" {
+					failed++
+					if result != "unavailable" {
+						t.Errorf("Expected check %s to report 'unavailable', got '%s'", check, result)
+					}
+					if len(tt.wantFailed) == 0 || check != tt.wantFailed[0] {
+						t.Errorf("Unexpected failed check %s: %s", check, result)
+					}
+				}
+			}
+			if failed != len(tt.wantFailed) {
+				t.Errorf("Expected %d failed checks, got %v", len(tt.wantFailed), ready.Checks)
+			}
+		})
+	}
+}
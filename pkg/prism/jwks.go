@@ -0,0 +1,154 @@
+package prism
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+const (
+	// jwksRefreshInterval is how long fetched keys are used before they are refreshed.
+	jwksRefreshInterval = 10 * time.Minute
+	// jwksMinRefreshInterval limits refreshes triggered by tokens signed with
+	// an unknown key, so forged key IDs cannot hammer the identity provider.
+	jwksMinRefreshInterval = 30 * time.Second
+)
+
+// jwksCache holds the signing keys of the identity provider. Keys are loaded
+// from a local file or an http(s) URL, refreshed periodically and re-fetched
+// early when a token names a key ID that is not cached yet, which picks up
+// key rotation without a restart.
+type jwksCache struct {
+	source     string
+	fileReader FileReader
+	client     *http.Client
+	now        func() time.Time
+
+	mu          sync.Mutex
+	keys        *jose.JSONWebKeySet
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	fetchErr    error         // of the last fetch
+	refreshing  chan struct{} // closed when the fetch in flight is done; nil when there is none
+}
+
+func newJWKSCache(source string, fileReader FileReader) *jwksCache {
+	return &jwksCache{
+		source:     source,
+		fileReader: fileReader,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+	}
+}
+
+// key returns the keys that can verify a token with kid and alg, refreshing
+// the set when it is stale or has no such key. A failed refresh keeps
+// serving the cached keys.
+//
+// Keys are fetched in the background, one fetch at a time. Only requests the
+// cached keys cannot serve wait for it, so a slow identity provider does not
+// hold up the others.
+func (c *jwksCache) key(ctx context.Context, kid, alg string) ([]jose.JSONWebKey, error) {
+	c.mu.Lock()
+	now := c.now()
+	stale := c.keys == nil || now.Sub(c.fetchedAt) >= jwksRefreshInterval
+	missing := c.keys != nil && len(matchKeys(c.keys, kid, alg)) == 0 && now.Sub(c.attemptedAt) >= jwksMinRefreshInterval
+	if (stale || missing) && c.refreshing == nil {
+		c.attemptedAt = now
+		c.refreshing = make(chan struct{})
+		// The fetch serves every waiting request, so it outlives this one
+		go c.refresh(context.WithoutCancel(ctx), now, c.refreshing)
+	}
+	keys, refreshing := c.keys, c.refreshing
+	c.mu.Unlock()
+
+	if refreshing != nil && (keys == nil || len(matchKeys(keys, kid, alg)) == 0) {
+		select {
+		case <-refreshing:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		c.mu.Lock()
+		keys = c.keys
+		err := c.fetchErr
+		c.mu.Unlock()
+		if keys == nil {
+			return nil, err
+		}
+	}
+
+	return matchKeys(keys, kid, alg), nil
+}
+
+// matchKeys returns the keys of set that can verify a token with kid and
+// alg. A token without a kid may be signed by any signing key for alg, such
+// as the only key of a set whose key has a kid.
+func matchKeys(set *jose.JSONWebKeySet, kid, alg string) []jose.JSONWebKey {
+	if kid != "" {
+		return set.Key(kid)
+	}
+	var keys []jose.JSONWebKey
+	for _, key := range set.Keys {
+		if (key.Algorithm == "" || key.Algorithm == alg) && (key.Use == "" || key.Use == "sig") {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// refresh fetches the keys without holding mu and swaps them in.
+func (c *jwksCache) refresh(ctx context.Context, startedAt time.Time, done chan struct{}) {
+	keys, err := c.fetch(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		c.keys = keys
+		c.fetchedAt = startedAt
+	}
+	c.fetchErr = err
+	c.refreshing = nil
+	close(done)
+}
+
+func (c *jwksCache) fetch(ctx context.Context) (*jose.JSONWebKeySet, error) {
+	var data []byte
+	if strings.HasPrefix(c.source, "http://") || strings.HasPrefix(c.source, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.source, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching JWKS: %w", err)
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching JWKS: %w", err)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("error fetching JWKS: unexpected status %s", resp.Status)
+		}
+		if data, err = io.ReadAll(io.LimitReader(resp.Body, 1<<20)); err != nil {
+			return nil, fmt.Errorf("error fetching JWKS: %w", err)
+		}
+	} else {
+		var err error
+		if data, err = c.fileReader.ReadFile(c.source); err != nil {
+			return nil, fmt.Errorf("error reading JWKS: %w", err)
+		}
+	}
+
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("error parsing JWKS: %w", err)
+	}
+	return &keys, nil
+}
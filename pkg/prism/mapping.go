@@ -0,0 +1,173 @@
+package prism
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+)
+
+// mapping holds the compiled templates of a route's MappingConfig.
+type mapping struct {
+	request             *template.Template
+	requestContentType  string
+	response            *template.Template
+	responseContentType string
+}
+
+// mappingRequest is the data request and response templates see as .Request
+// (or as . in the request template).
+type mappingRequest struct {
+	Method  string
+	Path    string
+	Params  map[string]string // path parameters
+	Query   url.Values
+	Header  http.Header
+	Body    any    // the body decoded as JSON, nil when it is not JSON
+	RawBody string // the body as sent
+}
+
+// mappingResponse is the data response templates see.
+type mappingResponse struct {
+	Status  int
+	Header  http.Header
+	Body    any    // the output decoded as JSON, nil when it is not JSON
+	RawBody string // the output as returned
+	Request *mappingRequest
+}
+
+var mappingFuncs = template.FuncMap{
+	// json encodes a value, so templates can place strings and objects into JSON safely
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// newMapping compiles cfg's templates.
+func newMapping(cfg *MappingConfig) (*mapping, error) {
+	m := &mapping{
+		requestContentType:  cfg.RequestContentType,
+		responseContentType: cfg.ResponseContentType,
+	}
+	if m.requestContentType == "" {
+		m.requestContentType = "application/json"
+	}
+	if m.responseContentType == "" {
+		m.responseContentType = "application/json"
+	}
+
+	// Fail on missing fields rather than rendering "<no value>"; optional
+	// fields can still be read with index, which yields nil.
+	newTemplate := func(name string) *template.Template {
+		return template.New(name).Funcs(mappingFuncs).Option("missingkey=error")
+	}
+
+	var err error
+	if cfg.Request != "" {
+		if m.request, err = newTemplate("request").Parse(cfg.Request); err != nil {
+			return nil, fmt.Errorf("invalid mapping.request: %w", err)
+		}
+	}
+	if cfg.Response != "" {
+		if m.response, err = newTemplate("response").Parse(cfg.Response); err != nil {
+			return nil, fmt.Errorf("invalid mapping.response: %w", err)
+		}
+	}
+	return m, nil
+}
+
+// mapRequest replaces the payload of req with the output of the route's
+// request template.
+func (rt *route) mapRequest(req *commpb.ExecuteRequest) error {
+	if rt.mapping == nil || rt.mapping.request == nil {
+		return nil
+	}
+
+	var out bytes.Buffer
+	if err := rt.mapping.request.Execute(&out, newMappingRequest(req)); err != nil {
+		return &HTTPError{
+			Code:      http.StatusBadRequest,
+			Message:   "Request cannot be mapped: " + err.Error(),
+			ErrorCode: "mapping_failed",
+		}
+	}
+	req.Body = out.Bytes()
+	req.ContentType = rt.mapping.requestContentType
+	return nil
+}
+
+// mapResponse replaces the output in resp with the output of the route's
+// response template. req is the invocation resp answers.
+func (rt *route) mapResponse(req *commpb.ExecuteRequest, resp *commpb.ExecuteResponse) error {
+	if rt.mapping == nil || rt.mapping.response == nil {
+		return nil
+	}
+
+	header := make(http.Header, len(resp.GetHttp().GetHeaders()))
+	for key, values := range resp.GetHttp().GetHeaders() {
+		header[http.CanonicalHeaderKey(key)] = values.GetValues()
+	}
+	data := &mappingResponse{
+		Status:  int(resp.GetHttp().GetStatusCode()),
+		Header:  header,
+		Body:    decodeJSON(resp.GetResp()),
+		RawBody: string(resp.GetResp()),
+		Request: newMappingRequest(req),
+	}
+	if data.Status == 0 {
+		data.Status = http.StatusOK
+	}
+
+	var out bytes.Buffer
+	if err := rt.mapping.response.Execute(&out, data); err != nil {
+		return &HTTPError{
+			Code:    http.StatusBadGateway,
+			Message: "Response of action " + req.GetAction() + " cannot be mapped: " + err.Error(),
+		}
+	}
+
+	resp.Resp = out.Bytes()
+	resp.ContentType = rt.mapping.responseContentType
+	// The function's description of its own body no longer applies
+	for key := range resp.GetHttp().GetHeaders() {
+		switch strings.ToLower(key) {
+		case "content-type", "etag", "content-encoding":
+			delete(resp.Http.Headers, key)
+		}
+	}
+	return nil
+}
+
+func newMappingRequest(req *commpb.ExecuteRequest) *mappingRequest {
+	event := req.GetHttp()
+	header := make(http.Header, len(event.GetHeaders()))
+	for key, values := range event.GetHeaders() {
+		header[key] = values.GetValues()
+	}
+	query, _ := url.ParseQuery(event.GetRawQuery())
+
+	return &mappingRequest{
+		Method:  event.GetMethod(),
+		Path:    event.GetPath(),
+		Params:  event.GetPathParams(),
+		Query:   query,
+		Header:  header,
+		Body:    decodeJSON(req.GetBody()),
+		RawBody: string(req.GetBody()),
+	}
+}
+
+// decodeJSON returns data decoded as JSON, or nil if it is not JSON.
+func decodeJSON(data []byte) any {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil
+	}
+	return v
+}
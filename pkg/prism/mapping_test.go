@@ -0,0 +1,108 @@
+package prism
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+)
+
+func TestServer_HandleAction_Mapping(t *testing.T) {
+	routes := map[string]string{
+		"orders.yml": `path: /shops/{shop}/orders
+method: POST
+action: orders.create
+mapping:
+  request: '{"shop":{{json .Params.shop}},"item":{{json .Body.name}},"channel":{{json (.Header.Get "X-Channel")}},"dry_run":{{eq (.Query.Get "dry") "1"}}}'
+  response: '{"id":{{json .Body.order_id}},"status":{{.Status}},"shop":{{json .Request.Params.shop}}}'`,
+	}
+
+	var payload string
+	var contentType string
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			payload, contentType = string(req.GetBody()), req.GetContentType()
+			return &commpb.ExecuteResponse{
+				Resp: []byte(`{"order_id":"o-1","internal":true}`),
+				Http: &pb.HttpResponse{
+					StatusCode: http.StatusCreated,
+					Headers: map[string]*pb.HeaderValues{
+						"Content-Type": {Values: []string{"application/vnd.legacy+json"}},
+						"X-Trace":      {Values: []string{"t-1"}},
+					},
+				},
+			}, nil
+		},
+	}
+	server := newTestServer(t, commClient, routeFiles(routes))
+
+	req := httptest.NewRequest(http.MethodPost, "/shops/acme/orders?dry=1", strings.NewReader(`{"name":"tea \"green\""}`))
+	req.Header.Set("X-Channel", "web")
+	w := httptest.NewRecorder()
+	server.handleAction(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if expected := `{"shop":"acme","item":"tea \"green\"","channel":"web","dry_run":true}`; payload != expected {
+		t.Errorf("Expected payload %s, got %s", expected, payload)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Expected payload content type application/json, got %s", contentType)
+	}
+	if expected := `{"id":"o-1","status":201,"shop":"acme"}`; w.Body.String() != expected {
+		t.Errorf("Expected body %s, got %s", expected, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %s", got)
+	}
+	if got := w.Header().Get("X-Trace"); got != "t-1" {
+		t.Errorf("Expected X-Trace to pass through, got %s", got)
+	}
+}
+
+func TestServer_HandleAction_MappingFailures(t *testing.T) {
+	routes := map[string]string{
+		"request.yml":  "path: /request\nmethod: POST\naction: request\nmapping:\n  request: '{{.Body.missing.field}}'",
+		"response.yml": "path: /response\nmethod: GET\naction: response\nmapping:\n  response: '{{index .Body 5}}'",
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantInvoke bool
+	}{
+		{name: "request template fails", method: http.MethodPost, path: "/request", body: `{"a":1}`, wantStatus: http.StatusBadRequest},
+		{name: "response template fails", method: http.MethodGet, path: "/response", wantStatus: http.StatusBadGateway, wantInvoke: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoked := false
+			commClient := &MockCommunicationClient{
+				SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+					invoked = true
+					return &commpb.ExecuteResponse{Resp: []byte(`{"a":1}`)}, nil
+				},
+			}
+			server := newTestServer(t, commClient, routeFiles(routes))
+
+			w := httptest.NewRecorder()
+			server.handleAction(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if invoked != tt.wantInvoke {
+				t.Errorf("Expected function invoked to be %v", tt.wantInvoke)
+			}
+		})
+	}
+}
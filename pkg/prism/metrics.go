@@ -0,0 +1,209 @@
+package prism
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// unmatchedRoute is the route label of requests that match no route or
+// none of its methods, and of CORS preflights and OPTIONS requests.
+const unmatchedRoute = "unmatched"
+
+// metrics are the Prometheus metrics of a server. Routes are labelled by
+// their path template, so the label set stays as small as the route table.
+type metrics struct {
+	registry *prometheus.Registry
+
+	requests       *prometheus.CounterVec
+	duration       *prometheus.HistogramVec
+	inFlight       *prometheus.GaugeVec
+	requestBytes   *prometheus.CounterVec
+	responseBytes  *prometheus.CounterVec
+	targets        *prometheus.CounterVec
+	upstreamErrors *prometheus.CounterVec
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "prism_http_requests_total",
+			Help: "HTTP requests served, by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "prism_http_request_duration_seconds",
+			Help:    "Time taken to serve HTTP requests, by route and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "prism_http_requests_in_flight",
+			Help: "HTTP requests being served, by route. Open WebSocket connections count until they close.",
+		}, []string{"route"}),
+		requestBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "prism_http_request_bytes_total",
+			Help: "Request body bytes read, by route.",
+		}, []string{"route"}),
+		responseBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "prism_http_response_bytes_total",
+			Help: "Response body bytes written, by route.",
+		}, []string{"route"}),
+		targets: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "prism_target_requests_total",
+			Help: "Requests served by each target of routes with targets, by route, action and status.",
+		}, []string{"route", "action", "status"}),
+		upstreamErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "prism_upstream_errors_total",
+			Help: "Failed calls to igniterelay, by action and gRPC status code.",
+		}, []string{"action", "code"}),
+	}
+	m.registry.MustRegister(
+		m.requests, m.duration, m.inFlight, m.requestBytes, m.responseBytes, m.targets, m.upstreamErrors,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// MetricsHandler serves the server's metrics in the Prometheus text format.
+func (s *Server) MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(s.metrics.registry, promhttp.HandlerOpts{})
+}
+
+// observe records a served request.
+func (m *metrics) observe(route, method string, rec *responseRecorder, bytesIn int64, elapsed time.Duration) {
+	method = methodLabel(method)
+	m.requests.WithLabelValues(route, method, statusLabel(rec.status)).Inc()
+	m.duration.WithLabelValues(route, method).Observe(elapsed.Seconds())
+	m.requestBytes.WithLabelValues(route).Add(float64(bytesIn))
+	m.responseBytes.WithLabelValues(route).Add(float64(rec.bytes))
+}
+
+// methodLabel keeps arbitrary methods sent by clients out of the label set.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodDelete, http.MethodPatch, http.MethodOptions:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
+// statusLabel formats a status, which is 200 when nothing was written.
+func statusLabel(code int) string {
+	if code == 0 {
+		code = http.StatusOK
+	}
+	return strconv.Itoa(code)
+}
+
+// meteredClient counts the errors of a CommunicationClient. Health checks
+// are not counted; readiness reports them.
+type meteredClient struct {
+	CommunicationClient
+	errors *prometheus.CounterVec
+}
+
+func (c *meteredClient) SendAction(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+	resp, err := c.CommunicationClient.SendAction(ctx, req)
+	c.observe(req.GetAction(), err)
+	return resp, err
+}
+
+func (c *meteredClient) SendActionStream(ctx context.Context, req *commpb.ExecuteRequest, send func(*commpb.ExecuteChunk) error) error {
+	err := c.CommunicationClient.SendActionStream(ctx, req, send)
+	c.observe(req.GetAction(), err)
+	return err
+}
+
+func (c *meteredClient) SendActionAsync(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.AsyncInvocation, error) {
+	inv, err := c.CommunicationClient.SendActionAsync(ctx, req)
+	c.observe(req.GetAction(), err)
+	return inv, err
+}
+
+func (c *meteredClient) GetInvocation(ctx context.Context, id string) (*commpb.AsyncInvocation, error) {
+	inv, err := c.CommunicationClient.GetInvocation(ctx, id)
+	// Unknown and expired invocations are the client's mistake, not igniterelay's
+	if status.Code(err) != codes.NotFound {
+		c.observe("", err)
+	}
+	return inv, err
+}
+
+func (c *meteredClient) observe(action string, err error) {
+	if err != nil {
+		c.errors.WithLabelValues(action, status.Code(err).String()).Inc()
+	}
+}
+
+// responseRecorder remembers the status and body size written through it.
+// It unwraps to the underlying writer so http.ResponseController can still
+// flush, and hijacks it for WebSocket upgrades.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseRecorder) WriteHeader(code int) {
+	// Informational responses precede the real one
+	if w.status == 0 && (code >= 200 || code == http.StatusSwitchingProtocols) {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *responseRecorder) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// countingReader counts the bytes read from a request body.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,68 @@
+package prism
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServer_Metrics(t *testing.T) {
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			if req.GetAction() == "broken" {
+				return nil, status.Error(codes.Unavailable, "no container")
+			}
+			return &commpb.ExecuteResponse{Resp: []byte(`{"ok":true}`)}, nil
+		},
+	}
+	server := newTestServer(t, commClient, routeFiles(map[string]string{
+		"users.yml":  "path: /users/{id}\nmethods: [GET, POST]\naction: users",
+		"broken.yml": "path: /broken\nmethod: GET\naction: broken",
+	}))
+	handler := server.Handler()
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/users/1", nil),
+		httptest.NewRequest(http.MethodGet, "/users/2", nil),
+		httptest.NewRequest(http.MethodPost, "/users/3", strings.NewReader(`{"name":"ada"}`)),
+		httptest.NewRequest(http.MethodGet, "/broken", nil),
+		httptest.NewRequest(http.MethodGet, "/missing", nil),
+		httptest.NewRequest("BREW", "/users/1", nil),
+		httptest.NewRequest(http.MethodGet, "/_invocations/expired", nil),
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	w := httptest.NewRecorder()
+	server.MetricsHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	body := w.Body.String()
+	for _, expected := range []string{
+		`prism_http_requests_total{method="GET",route="/users/{id}",status="200"} 2`,
+		`prism_http_requests_total{method="POST",route="/users/{id}",status="200"} 1`,
+		`prism_http_requests_total{method="GET",route="/broken",status="503"} 1`,
+		`prism_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`prism_http_requests_total{method="OTHER",route="unmatched",status="405"} 1`,
+		`prism_http_request_duration_seconds_count{method="GET",route="/users/{id}"} 2`,
+		`prism_http_requests_in_flight{route="/users/{id}"} 0`,
+		`prism_http_request_bytes_total{route="/users/{id}"} 14`,
+		`prism_http_response_bytes_total{route="/users/{id}"} 33`,
+		`prism_upstream_errors_total{action="broken",code="Unavailable"} 1`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected metrics to contain %s", expected)
+		}
+	}
+	if strings.Contains(body, `code="NotFound"`) {
+		t.Error("Expected unknown invocations not to count as upstream errors")
+	}
+}
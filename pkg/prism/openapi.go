@@ -0,0 +1,284 @@
+package prism
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Default title and version of the OpenAPI document.
+const (
+	DefaultAPITitle   = "NoctiFunc API"
+	DefaultAPIVersion = "1.0.0"
+)
+
+// Security schemes referenced by routes with auth.
+const (
+	apiKeyHeaderScheme = "apiKeyHeader"
+	apiKeyQueryScheme  = "apiKeyQuery"
+	bearerScheme       = "bearerAuth"
+)
+
+// WithAPIInfo sets the title and version reported in the OpenAPI document.
+func WithAPIInfo(title, version string) Option {
+	return func(s *Server) {
+		s.apiTitle = title
+		s.apiVersion = version
+	}
+}
+
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components *openAPIComponents                      `json:"components,omitempty"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIOperation struct {
+	OperationID string                  `json:"operationId"`
+	Summary     string                  `json:"summary,omitempty"`
+	Description string                  `json:"description,omitempty"`
+	Tags        []string                `json:"tags,omitempty"`
+	Parameters  []openAPIParameter      `json:"parameters,omitempty"`
+	RequestBody *openAPIBody            `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIBody `json:"responses"`
+	Security    []map[string][]string   `json:"security,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      map[string]any `json:"schema"`
+}
+
+// openAPIBody is a request body or a response.
+type openAPIBody struct {
+	Description string                      `json:"description,omitempty"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema map[string]any `json:"schema,omitempty"`
+}
+
+type openAPIComponents struct {
+	SecuritySchemes map[string]openAPISecurityScheme `json:"securitySchemes"`
+}
+
+type openAPISecurityScheme struct {
+	Type         string `json:"type"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// OpenAPI returns an OpenAPI 3.1 document describing the loaded routes, as
+// indented JSON.
+func (s *Server) OpenAPI() ([]byte, error) {
+	doc := s.routes.Load().openAPI(s.apiTitle, s.apiVersion)
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding OpenAPI document: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	data, err := s.OpenAPI()
+	if err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(data); err != nil {
+		s.log(r.Context()).Error().Err(err).Msg("Failed to write OpenAPI document")
+	}
+}
+
+// openAPI describes every route in the table. Routes are visited from most
+// to least specific, so operation IDs are assigned deterministically. Routes
+// matching the same paths share one OpenAPI path, named after the parameters
+// of the first of them, since OpenAPI treats /users/{id} and /users/{name}
+// as the same path.
+func (t *routeTable) openAPI(title, version string) *openAPIDocument {
+	doc := &openAPIDocument{
+		OpenAPI: "3.1.0",
+		Info:    openAPIInfo{Title: title, Version: version},
+		Paths:   make(map[string]map[string]*openAPIOperation),
+	}
+	schemes := make(map[string]openAPISecurityScheme)
+	operationIDs := make(map[string]bool)
+	templates := make(map[string]*pathTemplate) // by shape
+
+	for _, rt := range t.routes {
+		tmpl, ok := templates[rt.template.shape()]
+		if !ok {
+			tmpl = rt.template
+			templates[tmpl.shape()] = tmpl
+		}
+		path := tmpl.openAPIPath()
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*openAPIOperation)
+		}
+
+		methods := rt.config.AllowedMethods()
+		for _, method := range methods {
+			op := rt.openAPIOperation(method, tmpl)
+
+			op.OperationID = rt.config.Action
+			if len(methods) > 1 {
+				op.OperationID += "." + strings.ToLower(method)
+			}
+			for i := 2; operationIDs[op.OperationID]; i++ {
+				op.OperationID = fmt.Sprintf("%s.%s_%d", rt.config.Action, strings.ToLower(method), i)
+			}
+			operationIDs[op.OperationID] = true
+
+			for _, requirement := range op.Security {
+				for name := range requirement {
+					schemes[name] = securitySchemes[name]
+				}
+			}
+			doc.Paths[path][strings.ToLower(method)] = op
+		}
+	}
+
+	if len(schemes) > 0 {
+		doc.Components = &openAPIComponents{SecuritySchemes: schemes}
+	}
+	return doc
+}
+
+var securitySchemes = map[string]openAPISecurityScheme{
+	apiKeyHeaderScheme: {Type: "apiKey", In: "header", Name: apiKeyHeader},
+	apiKeyQueryScheme:  {Type: "apiKey", In: "query", Name: apiKeyQueryParam},
+	bearerScheme:       {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+}
+
+// openAPIOperation describes how rt serves method, without an operation ID.
+// Path parameters are named after tmpl, which has the shape of rt's template.
+func (rt *route) openAPIOperation(method string, tmpl *pathTemplate) *openAPIOperation {
+	cfg := rt.config
+	docs := cfg.Docs
+	if docs == nil {
+		docs = &DocsConfig{}
+	}
+
+	op := &openAPIOperation{
+		Summary:     docs.Summary,
+		Description: docs.Description,
+		Tags:        docs.Tags,
+		Responses:   make(map[string]*openAPIBody),
+	}
+
+	for _, seg := range tmpl.segments {
+		switch seg.kind {
+		case segmentParam:
+			op.Parameters = append(op.Parameters, openAPIParameter{
+				Name: seg.value, In: "path", Required: true, Schema: map[string]any{"type": "string"},
+			})
+		case segmentRest:
+			op.Parameters = append(op.Parameters, openAPIParameter{
+				Name: seg.value, In: "path", Required: true, Schema: map[string]any{"type": "string"},
+				Description: "Remainder of the path, which may contain slashes",
+			})
+		}
+	}
+	for _, p := range docs.Parameters {
+		schema := p.Schema
+		if schema == nil {
+			schema = map[string]any{"type": "string"}
+		}
+		op.Parameters = append(op.Parameters, openAPIParameter{
+			Name: p.Name, In: p.In, Description: p.Description, Required: p.Required, Schema: schema,
+		})
+	}
+
+	request := docs.Request
+	if rt.schema != nil && (request == nil || request.Schema == nil) {
+		// Document the schema requests are validated against
+		request = &BodyDoc{Schema: rt.schema.doc}
+		if docs.Request != nil {
+			request.Description, request.ContentType = docs.Request.Description, docs.Request.ContentType
+		}
+	}
+	if request != nil && method != http.MethodGet {
+		op.RequestBody = request.openAPI("")
+		if op.RequestBody.Content == nil {
+			// OpenAPI requires request bodies to list their content
+			op.RequestBody.Content = map[string]openAPIMediaType{"application/json": {}}
+		}
+	}
+
+	for code, body := range docs.Responses {
+		if body == nil {
+			body = &BodyDoc{}
+		}
+		// OpenAPI requires a description on every response
+		op.Responses[code] = body.openAPI("Response of the function")
+	}
+	if len(op.Responses) == 0 {
+		switch {
+		case cfg.WebSocket != nil:
+			op.Responses["101"] = &openAPIBody{Description: "Switches to the WebSocket protocol"}
+		case cfg.Invocation == InvocationAsync:
+			op.Responses["202"] = &openAPIBody{Description: "Invocation accepted; its status is at the Location header"}
+		default:
+			op.Responses["200"] = &openAPIBody{Description: "Response of the function"}
+		}
+	}
+
+	switch cfg.Auth {
+	case AuthAPIKey:
+		op.Security = []map[string][]string{{apiKeyHeaderScheme: {}}, {apiKeyQueryScheme: {}}}
+	case AuthJWT:
+		scopes := cfg.JWT.Scopes
+		if scopes == nil {
+			scopes = []string{}
+		}
+		op.Security = []map[string][]string{{bearerScheme: scopes}}
+	}
+	return op
+}
+
+// openAPI describes the body, using description when the route gives none.
+func (b *BodyDoc) openAPI(description string) *openAPIBody {
+	body := &openAPIBody{Description: b.Description}
+	if body.Description == "" {
+		body.Description = description
+	}
+	if b.Schema != nil || b.ContentType != "" {
+		contentType := b.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		body.Content = map[string]openAPIMediaType{contentType: {Schema: b.Schema}}
+	}
+	return body
+}
+
+// openAPIPath renders the template in OpenAPI syntax, where {rest...} becomes {rest}.
+func (t *pathTemplate) openAPIPath() string {
+	if len(t.segments) == 0 {
+		return "/"
+	}
+	var sb strings.Builder
+	for _, seg := range t.segments {
+		sb.WriteByte('/')
+		if seg.kind == segmentLiteral {
+			sb.WriteString(seg.value)
+		} else {
+			sb.WriteString("{" + seg.value + "}")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,117 @@
+package prism
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServer_OpenAPI(t *testing.T) {
+	server := newTestServer(t, &MockCommunicationClient{}, routeFiles(map[string]string{
+		"users.yml": `path: /users/{id}
+methods: [GET, PUT]
+action: users
+auth: jwt
+jwt:
+  issuer: https://issuer.example.com
+  audience: api
+  scopes: [users:write]
+docs:
+  summary: Read or replace a user
+  tags: [users]
+  parameters:
+    - name: fields
+      in: query
+  request:
+    schema:
+      type: object
+      required: [name]
+  responses:
+    200:
+      description: The user
+      schema: {type: object}
+    404:`,
+		"files.yml":  "path: /files/{rest...}\nmethod: GET\naction: files",
+		"export.yml": "path: /export\nmethod: POST\naction: export\ninvocation: async",
+	}))
+
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/_openapi.json", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected Content-Type 'application/json', got '%s'", w.Header().Get("Content-Type"))
+	}
+
+	var doc openAPIDocument
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to decode document: %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" || doc.Info.Title != DefaultAPITitle {
+		t.Errorf("Unexpected document header %q %v", doc.OpenAPI, doc.Info)
+	}
+
+	users := doc.Paths["/users/{id}"]
+	if users["get"] == nil || users["put"] == nil {
+		t.Fatalf("Expected get and put on /users/{id}, got %v", users)
+	}
+	get, put := users["get"], users["put"]
+	if get.OperationID != "users.get" || put.OperationID != "users.put" {
+		t.Errorf("Expected operation IDs users.get and users.put, got %s and %s", get.OperationID, put.OperationID)
+	}
+	if len(get.Parameters) != 2 || get.Parameters[0].In != "path" || get.Parameters[1].Name != "fields" {
+		t.Errorf("Expected path parameter id and query parameter fields, got %v", get.Parameters)
+	}
+	if get.RequestBody != nil {
+		t.Error("Expected no request body on GET")
+	}
+	if put.RequestBody == nil || put.RequestBody.Content["application/json"].Schema["type"] != "object" {
+		t.Errorf("Expected the request schema on PUT, got %v", put.RequestBody)
+	}
+	if put.Responses["404"] == nil || put.Responses["404"].Description == "" {
+		t.Errorf("Expected a described 404 response, got %v", put.Responses)
+	}
+	if len(put.Security) != 1 || put.Security[0][bearerScheme][0] != "users:write" {
+		t.Errorf("Expected bearer security with scope users:write, got %v", put.Security)
+	}
+	if doc.Components == nil || doc.Components.SecuritySchemes[bearerScheme].Scheme != "bearer" {
+		t.Errorf("Expected the bearer scheme in components, got %v", doc.Components)
+	}
+
+	if doc.Paths["/files/{rest}"]["get"] == nil {
+		t.Errorf("Expected /files/{rest} in the document, got %v", doc.Paths)
+	}
+	if export := doc.Paths["/export"]["post"]; export == nil || export.Responses["202"] == nil {
+		t.Errorf("Expected a 202 response for the async route, got %v", export)
+	}
+}
+
+func TestServer_OpenAPI_SharedPath(t *testing.T) {
+	server := newTestServer(t, &MockCommunicationClient{}, routeFiles(map[string]string{
+		"a_get_user.yml":    "path: /users/{id}\nmethod: GET\naction: get_user",
+		"b_rename_user.yml": "path: /users/{name}\nmethod: PATCH\naction: rename_user",
+	}))
+
+	data, err := server.OpenAPI()
+	if err != nil {
+		t.Fatalf("Failed to build document: %v", err)
+	}
+	var doc openAPIDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Failed to decode document: %v", err)
+	}
+
+	if len(doc.Paths) != 1 {
+		t.Fatalf("Expected a single path, got %v", doc.Paths)
+	}
+	users := doc.Paths["/users/{id}"]
+	if users["get"] == nil || users["patch"] == nil {
+		t.Fatalf("Expected get and patch on /users/{id}, got %v", users)
+	}
+	if params := users["patch"].Parameters; len(params) != 1 || params[0].Name != "id" {
+		t.Errorf("Expected path parameter id on patch, got %v", params)
+	}
+}
@@ -0,0 +1,210 @@
+package prism
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// rateLimitSweepInterval is how often buckets that have refilled are dropped.
+	rateLimitSweepInterval = time.Minute
+	// maxRateLimitBuckets bounds the buckets kept between sweeps. When it is
+	// reached the least recently used buckets are evicted.
+	maxRateLimitBuckets = 100_000
+)
+
+type bucketKey struct {
+	scope  string // "route", "ip" or "api_key"
+	route  string // file the route was loaded from
+	client string // client address or API key ID, empty for the route scope
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimiter holds the token buckets of all rate limited routes. A bucket
+// that has refilled completely behaves like a new one, so sweeps drop such
+// buckets without changing any limit, which keeps memory proportional to the
+// callers that are actually being limited.
+type rateLimiter struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		now:     time.Now,
+		buckets: make(map[bucketKey]*bucket),
+	}
+}
+
+type rateCheck struct {
+	key   bucketKey
+	limit *Limit
+}
+
+// rateDecision describes the most restrictive bucket a request was checked against.
+type rateDecision struct {
+	limit      int           // bucket size
+	remaining  int           // tokens left after the request
+	reset      time.Duration // until the bucket is full again
+	retryAfter time.Duration // until the request would be allowed, 0 when it is
+}
+
+// allow takes one token from every bucket in checks. If any bucket is empty
+// no token is taken from the others.
+func (l *rateLimiter) allow(checks []rateCheck) rateDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	limiters := make([]*rate.Limiter, len(checks))
+	reservations := make([]*rate.Reservation, len(checks))
+	var retryAfter time.Duration
+	for i, check := range checks {
+		limiters[i] = l.limiter(check.key, check.limit, now)
+		reservations[i] = limiters[i].ReserveN(now, 1)
+		retryAfter = max(retryAfter, reservations[i].DelayFrom(now))
+	}
+	if retryAfter > 0 {
+		for _, r := range reservations {
+			r.CancelAt(now)
+		}
+	}
+
+	decision := rateDecision{remaining: math.MaxInt, retryAfter: retryAfter}
+	for _, limiter := range limiters {
+		tokens := limiter.TokensAt(now)
+		remaining := max(int(tokens), 0)
+		if remaining >= decision.remaining {
+			continue
+		}
+		decision.limit = limiter.Burst()
+		decision.remaining = remaining
+		decision.reset = time.Duration((float64(limiter.Burst()) - tokens) / float64(limiter.Limit()) * float64(time.Second))
+	}
+	return decision
+}
+
+// limiter returns the limiter of key, creating it or applying a changed limit.
+func (l *rateLimiter) limiter(key bucketKey, limit *Limit, now time.Time) *rate.Limiter {
+	per := limit.Per
+	if per == 0 {
+		per = time.Second
+	}
+	r := rate.Limit(float64(limit.Requests) / per.Seconds())
+	burst := limit.Burst
+	if burst == 0 {
+		burst = limit.Requests
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxRateLimitBuckets {
+			l.evict()
+		}
+		b = &bucket{limiter: rate.NewLimiter(r, burst)}
+		l.buckets[key] = b
+	} else if b.limiter.Limit() != r || b.limiter.Burst() != burst {
+		b.limiter.SetLimitAt(now, r)
+		b.limiter.SetBurstAt(now, burst)
+	}
+	b.lastSeen = now
+	return b.limiter
+}
+
+// sweep drops the buckets that have refilled completely.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if b.limiter.TokensAt(now) >= float64(b.limiter.Burst()) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// evict drops the least recently used hundredth of the buckets.
+func (l *rateLimiter) evict() {
+	keys := make([]bucketKey, 0, len(l.buckets))
+	for key := range l.buckets {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b bucketKey) int {
+		return l.buckets[a].lastSeen.Compare(l.buckets[b].lastSeen)
+	})
+	for _, key := range keys[:max(len(keys)/100, 1)] {
+		delete(l.buckets, key)
+	}
+}
+
+// checkRateLimit applies the route's rate limits to r. The RateLimit headers
+// describe the most restrictive limit and are set on w for allowed requests;
+// rejected requests get a 429 carrying them along with Retry-After.
+func (s *Server) checkRateLimit(w http.ResponseWriter, r *http.Request, rt *route, identity *pb.Identity) error {
+	cfg := rt.config.RateLimit
+	if cfg == nil {
+		return nil
+	}
+
+	var checks []rateCheck
+	if cfg.Route != nil {
+		checks = append(checks, rateCheck{key: bucketKey{scope: "route", route: rt.source}, limit: cfg.Route})
+	}
+	if cfg.IP != nil {
+		checks = append(checks, rateCheck{key: bucketKey{scope: "ip", route: rt.source, client: clientIP(r)}, limit: cfg.IP})
+	}
+	if cfg.APIKey != nil && identity.GetApiKeyId() != "" {
+		checks = append(checks, rateCheck{key: bucketKey{scope: "api_key", route: rt.source, client: identity.GetApiKeyId()}, limit: cfg.APIKey})
+	}
+	if len(checks) == 0 {
+		return nil
+	}
+
+	decision := s.limiter.allow(checks)
+	header := http.Header{}
+	header.Set("RateLimit-Limit", strconv.Itoa(decision.limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(decision.remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.reset)))
+
+	if decision.retryAfter > 0 {
+		header.Set("Retry-After", strconv.Itoa(max(ceilSeconds(decision.retryAfter), 1)))
+		return &HTTPError{Code: http.StatusTooManyRequests, Message: "Rate limit exceeded", Header: header}
+	}
+
+	for key, values := range header {
+		w.Header()[key] = values
+	}
+	return nil
+}
+
+// clientIP returns the address of the peer that sent r.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
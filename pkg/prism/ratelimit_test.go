@@ -0,0 +1,113 @@
+package prism
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable time source for rate limiter tests.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newRateLimitServer(t *testing.T, rateLimit string) (*Server, *fakeClock) {
+	t.Helper()
+
+	server := newTestServer(t, &MockCommunicationClient{}, routeFiles(map[string]string{
+		"limited.yml": "path: /limited\nmethod: GET\naction: limited\nrate_limit:\n" + rateLimit,
+	}))
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	server.limiter.now = clock.now
+	return server, clock
+}
+
+func TestServer_RateLimit_PerIP(t *testing.T) {
+	server, clock := newRateLimitServer(t, "  ip:\n    requests: 2\n    per: 1m")
+
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		server.handleAction(w, req)
+		return w
+	}
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		w := send("10.0.0.1:1234")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status code %d, got %d", i, http.StatusOK, w.Code)
+		}
+		if got := w.Header().Get("RateLimit-Remaining"); got != wantRemaining {
+			t.Errorf("Request %d: expected RateLimit-Remaining %s, got %s", i, wantRemaining, got)
+		}
+		if got := w.Header().Get("RateLimit-Limit"); got != "2" {
+			t.Errorf("Request %d: expected RateLimit-Limit 2, got %s", i, got)
+		}
+	}
+
+	w := send("10.0.0.1:5678")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status code %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Expected Retry-After 30, got %s", got)
+	}
+	if got := w.Header().Get("RateLimit-Reset"); got != "60" {
+		t.Errorf("Expected RateLimit-Reset 60, got %s", got)
+	}
+
+	if w := send("10.0.0.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("Expected other clients to be allowed, got status code %d", w.Code)
+	}
+
+	clock.t = clock.t.Add(30 * time.Second)
+	if w := send("10.0.0.1:1234"); w.Code != http.StatusOK {
+		t.Errorf("Expected request after refill to be allowed, got status code %d", w.Code)
+	}
+}
+
+func TestServer_RateLimit_RejectedRequestKeepsTokens(t *testing.T) {
+	server, _ := newRateLimitServer(t, "  route:\n    requests: 3\n    per: 1h\n  ip:\n    requests: 1\n    per: 1h")
+
+	tests := []struct {
+		remoteAddr string
+		want       int
+	}{
+		{"10.0.0.1:1234", http.StatusOK},
+		{"10.0.0.1:1234", http.StatusTooManyRequests},
+		{"10.0.0.2:1234", http.StatusOK},
+		{"10.0.0.3:1234", http.StatusOK},
+		{"10.0.0.4:1234", http.StatusTooManyRequests},
+	}
+
+	for i, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		req.RemoteAddr = tt.remoteAddr
+		w := httptest.NewRecorder()
+		server.handleAction(w, req)
+
+		if w.Code != tt.want {
+			t.Errorf("Request %d from %s: expected status code %d, got %d", i, tt.remoteAddr, tt.want, w.Code)
+		}
+	}
+}
+
+func TestRateLimiter_Sweep(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := newRateLimiter()
+	limiter.now = clock.now
+
+	limit := &Limit{Requests: 1, Per: time.Hour}
+	for _, client := range []string{"a", "b", "c"} {
+		limiter.allow([]rateCheck{{key: bucketKey{scope: "ip", client: client}, limit: limit}})
+	}
+
+	clock.t = clock.t.Add(time.Hour)
+	limiter.allow([]rateCheck{{key: bucketKey{scope: "ip", client: "d"}, limit: limit}})
+
+	if len(limiter.buckets) != 1 {
+		t.Errorf("Expected refilled buckets to be swept, got %d buckets", len(limiter.buckets))
+	}
+}
@@ -0,0 +1,44 @@
+package prism
+
+import (
+	"net/http"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+)
+
+// newExecuteRequest builds the invocation of cfg's action for r. HEAD
+// requests invoke the function as GET, and the body is dropped when the
+// response is written, so functions never have to handle HEAD.
+func newExecuteRequest(r *http.Request, cfg *RouteConfig, params map[string]string, identity *pb.Identity, body []byte) *commpb.ExecuteRequest {
+	event := newHTTPRequestEvent(r, params)
+	event.Identity = identity
+	if event.Method == http.MethodHead {
+		event.Method = http.MethodGet
+	}
+
+	return &commpb.ExecuteRequest{
+		Action:      cfg.Action,
+		Body:        body,
+		ContentType: r.Header.Get("Content-Type"),
+		Http:        event,
+	}
+}
+
+// newHTTPRequestEvent describes r for the function. The body is sent separately.
+func newHTTPRequestEvent(r *http.Request, params map[string]string) *pb.HttpRequest {
+	headers := make(map[string]*pb.HeaderValues, len(r.Header))
+	for key, values := range r.Header {
+		headers[key] = &pb.HeaderValues{Values: values}
+	}
+
+	return &pb.HttpRequest{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		RawQuery:   r.URL.RawQuery,
+		Headers:    headers,
+		RemoteAddr: r.RemoteAddr,
+		Host:       r.Host,
+		PathParams: params,
+	}
+}
@@ -0,0 +1,116 @@
+package prism
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"time"
+
+	"github.com/Ow1Dev/NoctiFunc/pkg/communication"
+	"github.com/Ow1Dev/NoctiFunc/pkg/utils"
+	"github.com/rs/zerolog"
+)
+
+// maxRequestIDLength bounds the request IDs accepted from clients.
+const maxRequestIDLength = 128
+
+type (
+	requestLoggerKey struct{}
+	accessInfoKey    struct{}
+)
+
+// accessInfo is what handlers learn about a request for its access log line.
+type accessInfo struct {
+	route  string
+	action string
+}
+
+// withAccessLog gives every request served by mux a request ID and writes
+// its access log line once it has been served. The logged route is the mux
+// pattern the request matched, unless the handler records the route it
+// served with setAccessRoute.
+func (s *Server) withAccessLog(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		r = s.withRequestID(w, r)
+		_, pattern := mux.Handler(r)
+		info := &accessInfo{route: pattern}
+		r = r.WithContext(context.WithValue(r.Context(), accessInfoKey{}, info))
+		rec := &responseRecorder{ResponseWriter: w}
+		bodyReader := &countingReader{ReadCloser: r.Body}
+		r.Body = bodyReader
+		// Deferred so aborted streams are logged as well
+		defer func() {
+			s.logAccess(r, info, rec, bodyReader.n, time.Since(start))
+		}()
+
+		mux.ServeHTTP(rec, r)
+	})
+}
+
+// setAccessRoute records the route and action that served the request ctx
+// belongs to. action is empty when the request matched no route.
+func setAccessRoute(ctx context.Context, route, action string) {
+	if info, ok := ctx.Value(accessInfoKey{}).(*accessInfo); ok {
+		info.route, info.action = route, action
+	}
+}
+
+// withRequestID takes the request ID from the X-Request-ID header of r, or
+// generates one, and echoes it in the response. The returned request carries
+// the ID to igniterelay as gRPC metadata, to the function in its headers and
+// into the log lines written while serving it.
+func (s *Server) withRequestID(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := r.Header.Get(communication.RequestIDHeader)
+	if !validRequestID(id) {
+		id = rand.Text()
+	}
+	r.Header.Set(communication.RequestIDHeader, id)
+	w.Header().Set(communication.RequestIDHeader, id)
+
+	logger := s.logger.With().Str("request_id", id).Logger()
+	ctx := communication.WithRequestID(r.Context(), id)
+	return r.WithContext(context.WithValue(ctx, requestLoggerKey{}, &logger))
+}
+
+// validRequestID reports whether a client's request ID can be used as is.
+// gRPC metadata only carries printable ASCII; spaces are refused as well so
+// the ID stays a single token in logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// log returns the logger of the request ctx belongs to, which adds its ID.
+func (s *Server) log(ctx context.Context) *zerolog.Logger {
+	if logger, ok := ctx.Value(requestLoggerKey{}).(*zerolog.Logger); ok {
+		return logger
+	}
+	return &s.logger
+}
+
+// logAccess writes the access log line of a request.
+func (s *Server) logAccess(r *http.Request, info *accessInfo, rec *responseRecorder, bytesIn int64, elapsed time.Duration) {
+	event := s.log(r.Context()).Info().
+		Str("method", r.Method).
+		Str("path", r.URL.Path).
+		Str("route", info.route)
+	if info.action != "" {
+		event = event.Str("action", info.action)
+	}
+	event.
+		Int("status", utils.Ternary(rec.status != 0, rec.status, http.StatusOK)).
+		Int64("bytes_in", bytesIn).
+		Int64("bytes_out", rec.bytes).
+		Dur("duration", elapsed).
+		Str("remote_addr", r.RemoteAddr).
+		Str("user_agent", r.UserAgent()).
+		Msg("access")
+}
@@ -0,0 +1,192 @@
+package prism
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	"github.com/Ow1Dev/NoctiFunc/pkg/communication"
+	"github.com/rs/zerolog"
+)
+
+func TestServer_RequestID(t *testing.T) {
+	var sent, header string
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			sent = communication.RequestID(ctx)
+			header = req.GetHttp().GetHeaders()["X-Request-Id"].GetValues()[0]
+			return &commpb.ExecuteResponse{Resp: []byte(`{}`)}, nil
+		},
+	}
+	server := newTestServer(t, commClient, routeFiles(map[string]string{
+		"users.yml": "path: /users/{id}\nmethod: GET\naction: users",
+	}))
+
+	tests := []struct {
+		name      string
+		requestID string
+		keep      bool
+	}{
+		{name: "client ID", requestID: "req-1234", keep: true},
+		{name: "generated", requestID: ""},
+		{name: "with spaces", requestID: "two words"},
+		{name: "too long", requestID: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent, header = "", ""
+			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			if tt.requestID != "" {
+				req.Header.Set(communication.RequestIDHeader, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, req)
+
+			id := w.Header().Get(communication.RequestIDHeader)
+			if id == "" {
+				t.Fatal("Expected a request ID in the response")
+			}
+			if tt.keep && id != tt.requestID {
+				t.Errorf("Expected request ID %q, got %q", tt.requestID, id)
+			}
+			if !tt.keep && id == tt.requestID {
+				t.Errorf("Expected request ID %q to be replaced", tt.requestID)
+			}
+			if sent != id {
+				t.Errorf("Expected request ID %q in the gRPC metadata, got %q", id, sent)
+			}
+			if header != id {
+				t.Errorf("Expected request ID %q in the function's headers, got %q", id, header)
+			}
+		})
+	}
+}
+
+func TestServer_AccessLog(t *testing.T) {
+	var out bytes.Buffer
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			return &commpb.ExecuteResponse{Resp: []byte(`{"ok":true}`)}, nil
+		},
+	}
+	server := NewServer(commClient, routeFiles(map[string]string{
+		"users.yml": "path: /users/{id}\nmethod: POST\naction: users",
+	}), "/test/routes", zerolog.New(&out).Level(zerolog.InfoLevel))
+	if err := server.ReloadRoutes(); err != nil {
+		t.Fatalf("Failed to load routes: %v", err)
+	}
+	out.Reset()
+
+	req := httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader(`{"name":"ada"}`))
+	req.Header.Set(communication.RequestIDHeader, "req-1234")
+	server.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+	var line struct {
+		Message   string `json:"message"`
+		RequestID string `json:"request_id"`
+		Method    string `json:"method"`
+		Path      string `json:"path"`
+		Route     string `json:"route"`
+		Action    string `json:"action"`
+		Status    int    `json:"status"`
+		BytesIn   int64  `json:"bytes_in"`
+		BytesOut  int64  `json:"bytes_out"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("Expected a single JSON access log line, got %q: %v", out.String(), err)
+	}
+
+	if line.Message != "access" || line.RequestID != "req-1234" || line.Method != http.MethodPost ||
+		line.Path != "/users/1" || line.Route != "/users/{id}" || line.Action != "users" ||
+		line.Status != http.StatusOK || line.BytesIn != 14 || line.BytesOut != 11 {
+		t.Errorf("Unexpected access log line %s", out.String())
+	}
+}
+
+func TestServer_AccessLog_Endpoints(t *testing.T) {
+	var out bytes.Buffer
+	server := NewServer(&MockCommunicationClient{}, routeFiles(map[string]string{
+		"users.yml": "path: /users/{id}\nmethod: GET\naction: users",
+	}), "/test/routes", zerolog.New(&out).Level(zerolog.InfoLevel), WithAdminToken("secret"))
+	if err := server.ReloadRoutes(); err != nil {
+		t.Fatalf("Failed to load routes: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		method  string
+		target  string
+		route   string
+		status  int
+	}{
+		{name: "health", handler: server.Handler(), method: http.MethodGet, target: "/healthz", route: "GET /healthz", status: http.StatusOK},
+		{name: "openapi", handler: server.Handler(), method: http.MethodGet, target: "/_openapi.json", route: "GET /_openapi.json", status: http.StatusOK},
+		{name: "admin", handler: server.AdminHandler(), method: http.MethodGet, target: "/_admin/routes", route: "GET /_admin/routes", status: http.StatusUnauthorized},
+		{name: "admin unknown", handler: server.AdminHandler(), method: http.MethodGet, target: "/nothing", route: "", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Header.Set(communication.RequestIDHeader, "req-1234")
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, req)
+
+			if id := w.Header().Get(communication.RequestIDHeader); id != "req-1234" {
+				t.Errorf("Expected request ID 'req-1234', got '%s'", id)
+			}
+
+			var line struct {
+				Message   string `json:"message"`
+				RequestID string `json:"request_id"`
+				Route     string `json:"route"`
+				Status    int    `json:"status"`
+			}
+			if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+				t.Fatalf("Expected a single JSON access log line, got %q: %v", out.String(), err)
+			}
+			if line.Message != "access" || line.RequestID != "req-1234" || line.Route != tt.route || line.Status != tt.status {
+				t.Errorf("Unexpected access log line %s", out.String())
+			}
+		})
+	}
+}
+
+func TestServer_ErrorLogRequestID(t *testing.T) {
+	var out bytes.Buffer
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			return nil, errors.New("dial tcp 10.0.0.7:5001: connection refused")
+		},
+	}
+	server := NewServer(commClient, routeFiles(map[string]string{
+		"users.yml": "path: /users/{id}\nmethod: GET\naction: users",
+	}), "/test/routes", zerolog.New(&out).Level(zerolog.ErrorLevel))
+	if err := server.ReloadRoutes(); err != nil {
+		t.Fatalf("Failed to load routes: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(communication.RequestIDHeader, "req-1234")
+	server.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+	var line struct {
+		Message   string `json:"message"`
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("Expected a single JSON error log line, got %q: %v", out.String(), err)
+	}
+	if line.Message != "Upstream error" || line.RequestID != "req-1234" {
+		t.Errorf("Expected the upstream error logged with request ID 'req-1234', got %s", out.String())
+	}
+}
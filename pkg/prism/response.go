@@ -0,0 +1,80 @@
+package prism
+
+import (
+	"net/http"
+	"strconv"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	"github.com/Ow1Dev/NoctiFunc/pkg/utils"
+)
+
+// reservedResponseHeaders are managed by Prism and cannot be set by functions.
+var reservedResponseHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Keep-Alive":        true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+	"X-Request-Id":      true,
+}
+
+// writeResponse sends the function's response to the client, applying the
+// status code and headers the function chose.
+func (s *Server) writeResponse(w http.ResponseWriter, r *http.Request, resp *commpb.ExecuteResponse) {
+	status := http.StatusOK
+	if code := int(resp.GetHttp().GetStatusCode()); code != 0 {
+		if code < 200 || code > 599 {
+			s.handleError(w, r, &HTTPError{
+				Code:    http.StatusBadGateway,
+				Message: "Function returned invalid status code " + strconv.Itoa(code),
+			})
+			return
+		}
+		status = code
+	}
+
+	header := w.Header()
+	for key, values := range responseHeader(resp) {
+		header[key] = values
+	}
+
+	body := resp.GetResp()
+
+	if r.Method == http.MethodHead || !bodyAllowed(status) {
+		if bodyAllowed(status) {
+			header.Set("Content-Length", strconv.Itoa(len(body)))
+		}
+		w.WriteHeader(status)
+		return
+	}
+
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+
+	if _, err := w.Write(body); err != nil {
+		s.log(r.Context()).Error().Err(err).Msg("Failed to write response")
+	}
+}
+
+// responseHeader returns the headers the function set on resp, without the
+// ones Prism manages, and with a Content-Type for non-empty bodies.
+func responseHeader(resp *commpb.ExecuteResponse) http.Header {
+	header := make(http.Header)
+	for key, values := range resp.GetHttp().GetHeaders() {
+		key = http.CanonicalHeaderKey(key)
+		if reservedResponseHeaders[key] {
+			continue
+		}
+		header[key] = values.GetValues()
+	}
+
+	if header.Get("Content-Type") == "" && len(resp.GetResp()) > 0 {
+		header.Set("Content-Type", utils.Ternary(resp.GetContentType() != "", resp.GetContentType(), "application/json"))
+	}
+	return header
+}
+
+// bodyAllowed reports whether a response with status may include a body.
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
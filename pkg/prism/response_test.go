@@ -0,0 +1,161 @@
+package prism
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"github.com/rs/zerolog"
+)
+
+func TestServer_WriteResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		resp        *commpb.ExecuteResponse
+		code        int
+		body        string
+		contentType string
+		header      map[string][]string
+	}{
+		{
+			name:        "plain output",
+			method:      "GET",
+			resp:        &commpb.ExecuteResponse{Resp: []byte(`{"ok":true}`)},
+			code:        http.StatusOK,
+			body:        `{"ok":true}`,
+			contentType: "application/json",
+		},
+		{
+			name:   "created with headers and cookies",
+			method: "POST",
+			resp: &commpb.ExecuteResponse{
+				Resp: []byte("<p>created</p>"),
+				Http: &pb.HttpResponse{
+					StatusCode: http.StatusCreated,
+					Headers: map[string]*pb.HeaderValues{
+						"content-type": {Values: []string{"text/html"}},
+						"Set-Cookie":   {Values: []string{"a=1", "b=2"}},
+					},
+				},
+			},
+			code:        http.StatusCreated,
+			body:        "<p>created</p>",
+			contentType: "text/html",
+			header:      map[string][]string{"Set-Cookie": {"a=1", "b=2"}},
+		},
+		{
+			name:   "redirect",
+			method: "GET",
+			resp: &commpb.ExecuteResponse{
+				Http: &pb.HttpResponse{
+					StatusCode: http.StatusFound,
+					Headers: map[string]*pb.HeaderValues{
+						"Location": {Values: []string{"/elsewhere"}},
+					},
+				},
+			},
+			code:   http.StatusFound,
+			header: map[string][]string{"Location": {"/elsewhere"}},
+		},
+		{
+			name:   "no content",
+			method: "DELETE",
+			resp: &commpb.ExecuteResponse{
+				Resp: []byte("ignored"),
+				Http: &pb.HttpResponse{StatusCode: http.StatusNoContent},
+			},
+			code:        http.StatusNoContent,
+			contentType: "application/json",
+		},
+		{
+			name:   "reserved headers are dropped",
+			method: "GET",
+			resp: &commpb.ExecuteResponse{
+				Resp: []byte("abc"),
+				Http: &pb.HttpResponse{
+					Headers: map[string]*pb.HeaderValues{
+						"Content-Length":    {Values: []string{"999"}},
+						"Transfer-Encoding": {Values: []string{"chunked"}},
+					},
+				},
+			},
+			code:        http.StatusOK,
+			body:        "abc",
+			contentType: "application/json",
+			header:      map[string][]string{"Content-Length": {"3"}},
+		},
+		{
+			name:   "binary output with content type",
+			method: "GET",
+			resp: &commpb.ExecuteResponse{
+				Resp:        []byte("\x89PNG\r\n\x1a\n\xff\xfe"),
+				ContentType: "image/png",
+			},
+			code:        http.StatusOK,
+			body:        "\x89PNG\r\n\x1a\n\xff\xfe",
+			contentType: "image/png",
+		},
+		{
+			name:   "header content type wins",
+			method: "GET",
+			resp: &commpb.ExecuteResponse{
+				Resp:        []byte("hello"),
+				ContentType: "text/plain; charset=utf-8",
+				Http: &pb.HttpResponse{
+					Headers: map[string]*pb.HeaderValues{
+						"Content-Type": {Values: []string{"text/markdown"}},
+					},
+				},
+			},
+			code:        http.StatusOK,
+			body:        "hello",
+			contentType: "text/markdown",
+		},
+		{
+			name:   "invalid status",
+			method: "GET",
+			resp: &commpb.ExecuteResponse{
+				Http: &pb.HttpResponse{StatusCode: 42},
+			},
+			code: http.StatusBadGateway,
+		},
+	}
+
+	server := NewServer(nil, nil, "", zerolog.Nop())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			w := httptest.NewRecorder()
+
+			server.writeResponse(w, req, tt.resp)
+
+			if w.Code != tt.code {
+				t.Errorf("Expected status code %d, got %d", tt.code, w.Code)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("Expected body '%s', got '%s'", tt.body, w.Body.String())
+			}
+			if tt.code == http.StatusNoContent && w.Body.Len() != 0 {
+				t.Errorf("Expected empty body, got '%s'", w.Body.String())
+			}
+			if tt.contentType != "" && tt.body != "" && w.Header().Get("Content-Type") != tt.contentType {
+				t.Errorf("Expected Content-Type '%s', got '%s'", tt.contentType, w.Header().Get("Content-Type"))
+			}
+			for key, values := range tt.header {
+				got := w.Header().Values(key)
+				if len(got) != len(values) {
+					t.Errorf("Expected header %s=%v, got %v", key, values, got)
+					continue
+				}
+				for i := range values {
+					if got[i] != values[i] {
+						t.Errorf("Expected header %s=%v, got %v", key, values, got)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package prism
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	segmentLiteral segmentKind = iota
+	segmentParam
+	segmentRest
+)
+
+type segment struct {
+	kind  segmentKind
+	value string // literal text or parameter name
+}
+
+// pathTemplate is a parsed route path such as /users/{id}/orders/{orderId}
+// or /files/{rest...}. A {name...} segment captures the remainder of the path
+// and must be the last segment.
+type pathTemplate struct {
+	raw      string
+	segments []segment
+}
+
+func parsePathTemplate(path string) (*pathTemplate, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("path %q must start with /", path)
+	}
+
+	tmpl := &pathTemplate{raw: path}
+	parts := splitPath(path)
+	seen := make(map[string]bool, len(parts))
+
+	for i, part := range parts {
+		if !strings.ContainsAny(part, "{}") {
+			if part == "" {
+				return nil, fmt.Errorf("path %q contains an empty segment", path)
+			}
+			tmpl.segments = append(tmpl.segments, segment{kind: segmentLiteral, value: part})
+			continue
+		}
+
+		if !strings.HasPrefix(part, "{") || !strings.HasSuffix(part, "}") {
+			return nil, fmt.Errorf("path %q: parameter must span a whole segment: %q", path, part)
+		}
+
+		name := part[1 : len(part)-1]
+		kind := segmentParam
+		if strings.HasSuffix(name, "...") {
+			if i != len(parts)-1 {
+				return nil, fmt.Errorf("path %q: %q must be the last segment", path, part)
+			}
+			name = strings.TrimSuffix(name, "...")
+			kind = segmentRest
+		}
+
+		if name == "" || strings.ContainsAny(name, "{}.") {
+			return nil, fmt.Errorf("path %q: invalid parameter name %q", path, part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("path %q: duplicate parameter %q", path, name)
+		}
+		seen[name] = true
+
+		tmpl.segments = append(tmpl.segments, segment{kind: kind, value: name})
+	}
+
+	return tmpl, nil
+}
+
+// match reports whether path matches the template and returns the captured parameters.
+func (t *pathTemplate) match(path string) (map[string]string, bool) {
+	parts := splitPath(path)
+	params := make(map[string]string)
+
+	for i, seg := range t.segments {
+		if seg.kind == segmentRest {
+			params[seg.value] = strings.Join(parts[i:], "/")
+			return params, true
+		}
+		if i >= len(parts) {
+			return nil, false
+		}
+		switch seg.kind {
+		case segmentLiteral:
+			if parts[i] != seg.value {
+				return nil, false
+			}
+		case segmentParam:
+			if parts[i] == "" {
+				return nil, false
+			}
+			params[seg.value] = parts[i]
+		}
+	}
+
+	if len(parts) != len(t.segments) {
+		return nil, false
+	}
+	return params, true
+}
+
+// shape identifies templates that match exactly the same set of paths.
+func (t *pathTemplate) shape() string {
+	var sb strings.Builder
+	for _, seg := range t.segments {
+		sb.WriteByte('/')
+		switch seg.kind {
+		case segmentLiteral:
+			sb.WriteString(seg.value)
+		case segmentParam:
+			sb.WriteString("{}")
+		case segmentRest:
+			sb.WriteString("{...}")
+		}
+	}
+	return sb.String()
+}
+
+// moreSpecific orders templates so literal segments win over parameters and
+// parameters win over a trailing wildcard.
+func (t *pathTemplate) moreSpecific(other *pathTemplate) bool {
+	for i := 0; i < len(t.segments) && i < len(other.segments); i++ {
+		if t.segments[i].kind != other.segments[i].kind {
+			return t.segments[i].kind < other.segments[i].kind
+		}
+	}
+	return len(t.segments) < len(other.segments)
+}
+
+func splitPath(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+type route struct {
+	config   *RouteConfig
+	template *pathTemplate
+	source   string          // file the route was loaded from
+	schema   *compiledSchema // compiled request_schema, if any
+	mapping  *mapping        // compiled mapping templates, if any
+}
+
+// routeTable resolves request paths to route configurations. A table is
+// never modified once it has been published to the server.
+type routeTable struct {
+	routes []*route
+	owners map[string]*route
+}
+
+func newRouteTable(configs []*RouteConfig) (*routeTable, error) {
+	table := &routeTable{}
+	for _, cfg := range configs {
+		if _, err := table.add(cfg, ""); err != nil {
+			return nil, err
+		}
+	}
+	return table, nil
+}
+
+// add inserts cfg into the table, rejecting routes that serve the same
+// method on a path already owned by another route.
+func (t *routeTable) add(cfg *RouteConfig, source string) (*route, error) {
+	tmpl, err := parsePathTemplate(cfg.RoutePath())
+	if err != nil {
+		return nil, err
+	}
+
+	var m *mapping
+	if cfg.Mapping != nil {
+		if m, err = newMapping(cfg.Mapping); err != nil {
+			return nil, err
+		}
+	}
+
+	if t.owners == nil {
+		t.owners = make(map[string]*route)
+	}
+
+	methods := cfg.AllowedMethods()
+	for _, method := range methods {
+		if owner, ok := t.owners[method+" "+tmpl.shape()]; ok {
+			return nil, fmt.Errorf("route %s %s conflicts with %s", method, tmpl.raw, owner.template.raw)
+		}
+	}
+
+	rt := &route{config: cfg, template: tmpl, source: source, mapping: m}
+	for _, method := range methods {
+		t.owners[method+" "+tmpl.shape()] = rt
+	}
+
+	// Keep routes ordered from most to least specific so lookup can stop at the first match.
+	i := sort.Search(len(t.routes), func(i int) bool {
+		return tmpl.moreSpecific(t.routes[i].template)
+	})
+	t.routes = append(t.routes, nil)
+	copy(t.routes[i+1:], t.routes[i:])
+	t.routes[i] = rt
+
+	return rt, nil
+}
+
+// conflicts reports whether cfg, stored in file, would conflict with a
+// route loaded from another file. The route currently loaded from file, if
+// any, is the one cfg replaces.
+func (t *routeTable) conflicts(cfg *RouteConfig, file string) error {
+	others := &routeTable{}
+	for _, rt := range t.routes {
+		if rt.source == file {
+			continue
+		}
+		if _, err := others.add(rt.config, rt.source); err != nil {
+			return err
+		}
+	}
+	_, err := others.add(cfg, file)
+	return err
+}
+
+// lookup finds the most specific route matching method and path. When the
+// path exists but not for method, the returned 405 error lists the allowed methods.
+func (t *routeTable) lookup(method, path string) (*route, map[string]string, error) {
+	pathMatched := false
+	for _, rt := range t.routes {
+		params, ok := rt.template.match(path)
+		if !ok {
+			continue
+		}
+		pathMatched = true
+		if rt.config.Allows(method) {
+			return rt, params, nil
+		}
+	}
+
+	if pathMatched {
+		return nil, nil, &HTTPError{
+			Code:    http.StatusMethodNotAllowed,
+			Message: "Method not allowed for path " + path,
+			Header:  http.Header{"Allow": {strings.Join(t.allow(path), ", ")}},
+		}
+	}
+
+	return nil, nil, &HTTPError{
+		Code:    http.StatusNotFound,
+		Message: "No route found for path " + path,
+	}
+}
+
+// find returns the route serving method on the path template, or nil.
+func (t *routeTable) find(method, template string) *route {
+	for _, rt := range t.routes {
+		if rt.template.raw == template && rt.config.Allows(method) {
+			return rt
+		}
+	}
+	return nil
+}
+
+// allow returns the methods served on path by any route, including the
+// implicit HEAD and OPTIONS. It returns nil when no route matches the path.
+func (t *routeTable) allow(path string) []string {
+	seen := make(map[string]bool)
+	for _, rt := range t.routes {
+		if _, ok := rt.template.match(path); !ok {
+			continue
+		}
+		for _, method := range rt.config.AllowedMethods() {
+			seen[method] = true
+		}
+	}
+
+	if len(seen) == 0 {
+		return nil
+	}
+	if seen[http.MethodGet] {
+		seen[http.MethodHead] = true
+	}
+	seen[http.MethodOptions] = true
+
+	allow := make([]string, 0, len(seen))
+	for _, method := range methodOrder {
+		if seen[method] {
+			allow = append(allow, method)
+		}
+	}
+	return allow
+}
+
+// methodOrder is the order methods are listed in Allow headers.
+var methodOrder = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
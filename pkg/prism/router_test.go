@@ -0,0 +1,137 @@
+package prism
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParsePathTemplate_Invalid(t *testing.T) {
+	tests := []string{
+		"users",
+		"/users//orders",
+		"/users/{id",
+		"/users/id}",
+		"/users/x{id}",
+		"/users/{}",
+		"/files/{rest...}/tail",
+		"/users/{id}/orders/{id}",
+	}
+
+	for _, path := range tests {
+		t.Run(path, func(t *testing.T) {
+			if _, err := parsePathTemplate(path); err == nil {
+				t.Errorf("Expected error for path %q", path)
+			}
+		})
+	}
+}
+
+func TestPathTemplate_Match(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+		match    bool
+		params   map[string]string
+	}{
+		{"/", "/", true, map[string]string{}},
+		{"/echo", "/echo", true, map[string]string{}},
+		{"/echo", "/echo/more", false, nil},
+		{"/users/{id}", "/users/42", true, map[string]string{"id": "42"}},
+		{"/users/{id}", "/users/", false, nil},
+		{"/users/{id}/orders/{orderId}", "/users/1/orders/2", true, map[string]string{"id": "1", "orderId": "2"}},
+		{"/files/{rest...}", "/files/a/b/c.txt", true, map[string]string{"rest": "a/b/c.txt"}},
+		{"/files/{rest...}", "/files", true, map[string]string{"rest": ""}},
+		{"/files/{rest...}", "/other/a", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template+" "+tt.path, func(t *testing.T) {
+			tmpl, err := parsePathTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			params, ok := tmpl.match(tt.path)
+			if ok != tt.match {
+				t.Fatalf("Expected match %v, got %v", tt.match, ok)
+			}
+			if ok && !reflect.DeepEqual(params, tt.params) {
+				t.Errorf("Expected params %v, got %v", tt.params, params)
+			}
+		})
+	}
+}
+
+func TestRouteTable_Lookup(t *testing.T) {
+	table, err := newRouteTable([]*RouteConfig{
+		{Path: "/users/{id}", Action: "users.get", Method: "GET"},
+		{Path: "/users/me", Action: "users.me", Method: "GET"},
+		{Path: "/files/{rest...}", Action: "files.get", Method: "GET"},
+		{Action: "legacy.action", Method: "POST"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		action string
+		code   int
+	}{
+		{"GET", "/users/me", "users.me", 0},
+		{"GET", "/users/7", "users.get", 0},
+		{"GET", "/files/x/y", "files.get", 0},
+		{"POST", "/legacy/action", "legacy.action", 0},
+		{"POST", "/users/7", "", http.StatusMethodNotAllowed},
+		{"GET", "/missing", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rt, _, err := table.lookup(tt.method, tt.path)
+			if tt.code != 0 {
+				httpErr, ok := err.(*HTTPError)
+				if !ok || httpErr.Code != tt.code {
+					t.Fatalf("Expected HTTPError %d, got %v", tt.code, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if rt.config.Action != tt.action {
+				t.Errorf("Expected action %q, got %q", tt.action, rt.config.Action)
+			}
+		})
+	}
+}
+
+func TestNewRouteTable_MethodsOnSamePath(t *testing.T) {
+	_, err := newRouteTable([]*RouteConfig{
+		{Path: "/users/{id}", Action: "a", Methods: []string{"GET", "PUT"}},
+		{Path: "/users/{name}", Action: "b", Method: "DELETE"},
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	_, err = newRouteTable([]*RouteConfig{
+		{Path: "/users/{id}", Action: "a", Methods: []string{"GET", "PUT"}},
+		{Path: "/users/{name}", Action: "b", Methods: []string{"PUT"}},
+	})
+	if err == nil {
+		t.Error("Expected conflict error, got nil")
+	}
+}
+
+func TestNewRouteTable_Conflict(t *testing.T) {
+	_, err := newRouteTable([]*RouteConfig{
+		{Path: "/users/{id}", Action: "a", Method: "GET"},
+		{Path: "/users/{name}", Action: "b", Method: "GET"},
+	})
+	if err == nil {
+		t.Error("Expected conflict error, got nil")
+	}
+}
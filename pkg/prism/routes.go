@@ -0,0 +1,145 @@
+package prism
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ReloadRoutes reads every route file in the routes directory and atomically
+// swaps in a new route table if any file changed. Files that fail to parse,
+// validate or that conflict with an earlier route are logged and skipped.
+func (s *Server) ReloadRoutes() error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	return s.reloadRoutesLocked()
+}
+
+// reloadRoutesLocked is ReloadRoutes for callers holding reloadMu.
+func (s *Server) reloadRoutesLocked() error {
+	files, err := s.readRouteFiles()
+	s.routesOK.Store(err == nil)
+	if err != nil {
+		return err
+	}
+
+	schemaFiles := s.readSchemaFiles(files)
+
+	if s.routeFiles != nil && maps.Equal(files, s.routeFiles) && maps.Equal(schemaFiles, s.schemaFiles) {
+		return nil
+	}
+
+	table := s.buildRouteTable(files, schemaFiles)
+	s.routes.Store(table)
+	s.routeFiles = files
+	s.schemaFiles = schemaFiles
+
+	s.logger.Info().Msgf("Loaded %d routes from %s", len(table.routes), s.routesPath)
+	return nil
+}
+
+// WatchRoutes polls the routes directory and the API key store every interval
+// and reloads them when they change. It blocks until ctx is done.
+func (s *Server) WatchRoutes(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ReloadRoutes(); err != nil {
+				s.logger.Error().Err(err).Msg("Failed to reload routes")
+			}
+			if err := s.ReloadAPIKeys(); err != nil {
+				s.logger.Error().Err(err).Msg("Failed to reload API keys")
+			}
+		}
+	}
+}
+
+// readRouteFiles returns the contents of every route file keyed by file name.
+func (s *Server) readRouteFiles() (map[string]string, error) {
+	names, err := s.fileReader.ListFiles(s.routesPath)
+	if err != nil {
+		return nil, fmt.Errorf("error listing routes: %w", err)
+	}
+
+	files := make(map[string]string, len(names))
+	for _, name := range names {
+		if !isRouteFile(name) {
+			continue
+		}
+
+		data, err := s.fileReader.ReadFile(filepath.Join(s.routesPath, name))
+		if err != nil {
+			s.logger.Error().Err(err).Msgf("Skipping unreadable route file %s", name)
+			continue
+		}
+		files[name] = string(data)
+	}
+
+	return files, nil
+}
+
+func (s *Server) buildRouteTable(files, schemaFiles map[string]string) *routeTable {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	// Load in name order so conflicts always resolve the same way.
+	sort.Strings(names)
+
+	table := &routeTable{}
+	schemas := make(map[string]*compiledSchema)
+	for _, name := range names {
+		cfg, err := parseRouteConfig([]byte(files[name]))
+		if err != nil {
+			s.logger.Error().Err(err).Msgf("Skipping invalid route file %s", name)
+			continue
+		}
+
+		var schema *compiledSchema
+		if cfg.RequestSchema != nil {
+			if schema, err = s.compileSchema(cfg.RequestSchema, schemaFiles, schemas); err != nil {
+				s.logger.Error().Err(err).Msgf("Skipping route file %s", name)
+				continue
+			}
+		}
+
+		rt, err := table.add(cfg, name)
+		if err != nil {
+			s.logger.Error().Err(err).Msgf("Skipping route file %s", name)
+			continue
+		}
+		rt.schema = schema
+	}
+
+	// Only keep the schemas still in use
+	s.schemas = schemas
+	return table
+}
+
+// parseRouteConfig parses and validates a single route file.
+func parseRouteConfig(data []byte) (*RouteConfig, error) {
+	cfg, err := loadFromYaml(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("error validating route config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+func isRouteFile(name string) bool {
+	ext := filepath.Ext(name)
+	return ext == ".yml" || ext == ".yaml"
+}
@@ -0,0 +1,171 @@
+package prism
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// Violation is one way a request body fails its route's request_schema.
+type Violation struct {
+	Path    string `json:"path"`    // JSON Pointer to the offending value, empty for the whole body
+	Keyword string `json:"keyword"` // JSON Pointer to the schema keyword that failed
+	Message string `json:"message"`
+}
+
+// compiledSchema is a request_schema ready for validation, along with the
+// document it was compiled from for the OpenAPI document.
+type compiledSchema struct {
+	schema *jsonschema.Schema
+	doc    map[string]any
+}
+
+// schemaPath resolves a request_schema file against the routes directory.
+func (s *Server) schemaPath(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(s.routesPath, file)
+}
+
+// readSchemaFiles returns the contents of the schema files the route files
+// reference, keyed by resolved path, so changes to them trigger a reload.
+// Files that cannot be read are left out and fail their route when it is built.
+func (s *Server) readSchemaFiles(routeFiles map[string]string) map[string]string {
+	files := make(map[string]string)
+	for _, data := range routeFiles {
+		cfg, err := loadFromYaml([]byte(data))
+		if err != nil || cfg.RequestSchema == nil || cfg.RequestSchema.File == "" {
+			continue
+		}
+
+		path := s.schemaPath(cfg.RequestSchema.File)
+		if _, ok := files[path]; ok {
+			continue
+		}
+		content, err := s.fileReader.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		files[path] = string(content)
+	}
+	return files
+}
+
+// compileSchema compiles cfg, reusing the schema compiled by an earlier
+// reload when its source is unchanged. Every schema in use is recorded in
+// compiled, which becomes the cache for the next reload.
+func (s *Server) compileSchema(cfg *SchemaConfig, schemaFiles map[string]string, compiled map[string]*compiledSchema) (*compiledSchema, error) {
+	var data []byte
+	var url string
+	if cfg.File != "" {
+		path := s.schemaPath(cfg.File)
+		content, ok := schemaFiles[path]
+		if !ok {
+			return nil, fmt.Errorf("request_schema file %s cannot be read", path)
+		}
+		data, url = []byte(content), path
+	} else {
+		var err error
+		if data, err = json.Marshal(cfg.Inline); err != nil {
+			return nil, fmt.Errorf("request_schema: %w", err)
+		}
+	}
+
+	sum := sha256.Sum256(data)
+	key := url + "\x00" + hex.EncodeToString(sum[:])
+	if cs, ok := compiled[key]; ok {
+		return cs, nil
+	}
+	if cs, ok := s.schemas[key]; ok {
+		compiled[key] = cs
+		return cs, nil
+	}
+
+	if url == "" {
+		url = "inline://" + hex.EncodeToString(sum[:]) + ".json"
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("request_schema %s is not a JSON object: %w", url, err)
+	}
+	resource, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("request_schema %s: %w", url, err)
+	}
+
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(url, resource); err != nil {
+		return nil, fmt.Errorf("request_schema %s: %w", url, err)
+	}
+	schema, err := c.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("request_schema %s: %w", url, err)
+	}
+
+	cs := &compiledSchema{schema: schema, doc: doc}
+	compiled[key] = cs
+	return cs, nil
+}
+
+// validateBody checks body against the route's request schema, so invalid
+// requests are answered without invoking the function. GET and HEAD
+// requests carry no body and are not checked.
+func (rt *route) validateBody(method string, body []byte) error {
+	if rt.schema == nil || method == http.MethodGet || method == http.MethodHead {
+		return nil
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return &HTTPError{
+			Code:      http.StatusBadRequest,
+			Message:   "Request body is not valid JSON",
+			ErrorCode: "invalid_json",
+		}
+	}
+
+	err = rt.schema.schema.Validate(instance)
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		found := violations(validationErr.DetailedOutput())
+		// The validator visits properties in map order, so sort for stable responses
+		slices.SortFunc(found, func(a, b Violation) int {
+			return cmp.Or(strings.Compare(a.Path, b.Path), strings.Compare(a.Keyword, b.Keyword))
+		})
+		return &HTTPError{
+			Code:       http.StatusBadRequest,
+			Message:    "Request body does not match the schema",
+			ErrorCode:  "schema_violation",
+			Violations: found,
+		}
+	}
+	return err
+}
+
+// violations flattens the leaves of a validation output, which are the
+// individual failures; inner units only group them.
+func violations(unit *jsonschema.OutputUnit) []Violation {
+	if len(unit.Errors) == 0 {
+		if unit.Error == nil {
+			return nil
+		}
+		return []Violation{{Path: unit.InstanceLocation, Keyword: unit.KeywordLocation, Message: unit.Error.String()}}
+	}
+
+	var out []Violation
+	for i := range unit.Errors {
+		out = append(out, violations(&unit.Errors[i])...)
+	}
+	return out
+}
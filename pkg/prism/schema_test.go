@@ -0,0 +1,142 @@
+package prism
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+)
+
+const orderSchema = `{
+  "type": "object",
+  "required": ["item", "quantity"],
+  "properties": {
+    "item": {"type": "string"},
+    "quantity": {"type": "integer", "minimum": 1}
+  }
+}`
+
+func TestServer_HandleAction_RequestSchema(t *testing.T) {
+	routes := map[string]string{
+		"inline.yml": `path: /orders
+methods: [GET, POST]
+action: orders
+request_schema:
+  inline:
+    type: object
+    required: [item, quantity]
+    properties:
+      item: {type: string}
+      quantity: {type: integer, minimum: 1}`,
+		"file.yml":          "path: /returns\nmethod: POST\naction: returns\nrequest_schema:\n  file: order.schema.json",
+		"order.schema.json": orderSchema,
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		wantStatus     int
+		wantViolations []string // instance locations
+	}{
+		{name: "valid inline", method: http.MethodPost, path: "/orders", body: `{"item":"tea","quantity":2}`, wantStatus: http.StatusOK},
+		{name: "valid file", method: http.MethodPost, path: "/returns", body: `{"item":"tea","quantity":2}`, wantStatus: http.StatusOK},
+		{name: "GET is not validated", method: http.MethodGet, path: "/orders", wantStatus: http.StatusOK},
+		{
+			name: "violations", method: http.MethodPost, path: "/orders", body: `{"item":3,"quantity":0}`,
+			wantStatus: http.StatusBadRequest, wantViolations: []string{"/item", "/quantity"},
+		},
+		{
+			name: "missing property from file schema", method: http.MethodPost, path: "/returns", body: `{"item":"tea"}`,
+			wantStatus: http.StatusBadRequest, wantViolations: []string{""},
+		},
+		{name: "invalid JSON", method: http.MethodPost, path: "/orders", body: `{"item":`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoked := false
+			commClient := &MockCommunicationClient{
+				SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+					invoked = true
+					return &commpb.ExecuteResponse{Resp: []byte(`{}`)}, nil
+				},
+			}
+			server := newTestServer(t, commClient, routeFiles(routes))
+
+			w := httptest.NewRecorder()
+			server.handleAction(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if invoked != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("Expected function invoked to be %v", tt.wantStatus == http.StatusOK)
+			}
+			if tt.wantViolations == nil {
+				return
+			}
+
+			var body struct {
+				Code       string      `json:"code"`
+				Violations []Violation `json:"violations"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to decode error body: %v", err)
+			}
+			if body.Code != "schema_violation" {
+				t.Errorf("Expected code 'schema_violation', got '%s'", body.Code)
+			}
+			var paths []string
+			for _, v := range body.Violations {
+				if v.Message == "" {
+					t.Errorf("Expected a message for violation at %q", v.Path)
+				}
+				paths = append(paths, v.Path)
+			}
+			if strings.Join(paths, ",") != strings.Join(tt.wantViolations, ",") {
+				t.Errorf("Expected violations at %q, got %q", tt.wantViolations, paths)
+			}
+		})
+	}
+}
+
+func TestServer_ReloadRoutes_SchemaCache(t *testing.T) {
+	files := map[string]string{
+		"file.yml":          "path: /returns\nmethod: POST\naction: returns\nrequest_schema:\n  file: order.schema.json",
+		"other.yml":         "path: /other\nmethod: GET\naction: other",
+		"order.schema.json": orderSchema,
+	}
+	server := newTestServer(t, &MockCommunicationClient{}, routeFiles(files))
+	schema := func() *compiledSchema {
+		rt, _, err := server.routes.Load().lookup(http.MethodPost, "/returns")
+		if err != nil {
+			t.Fatalf("Failed to look up route: %v", err)
+		}
+		return rt.schema
+	}
+	first := schema()
+
+	// Changing another route rebuilds the table but keeps the compiled schema
+	files["other.yml"] = "path: /other\nmethod: GET\naction: other.v2"
+	if err := server.ReloadRoutes(); err != nil {
+		t.Fatalf("Failed to reload routes: %v", err)
+	}
+	if schema() != first {
+		t.Error("Expected the unchanged schema to be reused")
+	}
+
+	// Changing only the schema file triggers a reload with the new schema
+	files["order.schema.json"] = `{"type": "array"}`
+	if err := server.ReloadRoutes(); err != nil {
+		t.Fatalf("Failed to reload routes: %v", err)
+	}
+	if schema() == first || schema().doc["type"] != "array" {
+		t.Errorf("Expected the changed schema to be compiled, got %v", schema().doc)
+	}
+}
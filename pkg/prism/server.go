@@ -2,23 +2,45 @@ package prism
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
-
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Ow1Dev/NoctiFunc/internal/apikey"
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"github.com/Ow1Dev/NoctiFunc/pkg/communication"
+	"github.com/Ow1Dev/NoctiFunc/pkg/utils"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type CommunicationClient interface {
-	SendAction(ctx context.Context, action, body string) (string, error)
+	SendAction(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error)
+	SendActionStream(ctx context.Context, req *commpb.ExecuteRequest, send func(*commpb.ExecuteChunk) error) error
+	SendActionAsync(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.AsyncInvocation, error)
+	GetInvocation(ctx context.Context, id string) (*commpb.AsyncInvocation, error)
+	Health(ctx context.Context) error
 }
 
 type FileReader interface {
 	ReadFile(filePath string) ([]byte, error)
-	FileExists(filePath string) bool
+	ListFiles(dirPath string) ([]string, error)
+}
+
+// FileWriter changes route files for the admin API.
+type FileWriter interface {
+	WriteFile(filePath string, data []byte) error // replaces the file atomically
+	RemoveFile(filePath string) error
 }
 
 type OSFileReader struct{}
@@ -27,39 +49,162 @@ func (r *OSFileReader) ReadFile(filePath string) ([]byte, error) {
 	return os.ReadFile(filePath)
 }
 
-func (r *OSFileReader) FileExists(filePath string) bool {
-	_, err := os.Stat(filePath)
-	return !os.IsNotExist(err)
+// ListFiles returns the names of the regular files in dirPath.
+func (r *OSFileReader) ListFiles(dirPath string) ([]string, error) {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// OSFileWriter writes files by renaming a temporary file over them, so
+// readers never see a partially written file.
+type OSFileWriter struct{}
+
+func (w *OSFileWriter) WriteFile(filePath string, data []byte) error {
+	dir, name := filepath.Split(filePath)
+	// The temporary name has no route file extension, so a reload never picks it up
+	tmp, err := os.CreateTemp(dir, "."+name+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// Removing fails harmlessly once the file has been renamed
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if err := writeAndSync(tmp, data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filePath)
+}
+
+func writeAndSync(f *os.File, data []byte) error {
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	if err := f.Chmod(0o644); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func (w *OSFileWriter) RemoveFile(filePath string) error {
+	return os.Remove(filePath)
 }
 
 type Server struct {
 	commClient CommunicationClient
 	fileReader FileReader
+	fileWriter FileWriter
 	routesPath string
 	logger     zerolog.Logger
+
+	maxBodyBytes int64
+	timeout      time.Duration
+
+	routes     atomic.Pointer[routeTable]
+	reloadMu   sync.Mutex
+	routeFiles map[string]string // file contents the current table was built from
+	routesOK   atomic.Bool       // whether the last reload could read the routes directory
+
+	schemaFiles map[string]string          // request_schema files the current table was built from
+	schemas     map[string]*compiledSchema // compiled request schemas by source, reused across reloads
+
+	apiKeysPath string
+	apiKeys     atomic.Pointer[apikey.Store]
+	apiKeysData string // key store contents apiKeys was parsed from
+
+	jwks *jwksCache
+
+	limiter *rateLimiter
+	cache   *responseCache
+	conns   *connectionHub
+	targets *targetCounter
+	metrics *metrics
+
+	adminToken string
+
+	apiTitle   string
+	apiVersion string
+}
+
+// DefaultTimeout bounds invocations of routes without a timeout, including
+// the cold start of the function.
+const DefaultTimeout = 30 * time.Second
+
+type Option func(*Server)
+
+// WithMaxBodyBytes sets the request body limit for routes without max_body_bytes.
+func WithMaxBodyBytes(n int64) Option {
+	return func(s *Server) {
+		s.maxBodyBytes = n
+	}
 }
 
-func NewServer(commClient CommunicationClient, fileReader FileReader, routesPath string, logger zerolog.Logger) *Server {
-	return &Server{
-		commClient: commClient,
-		fileReader: fileReader,
-		routesPath: routesPath,
-		logger:     logger.With().Str("component", "prism_server").Logger(),
+// WithTimeout sets the invocation timeout for routes without a timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.timeout = d
 	}
 }
 
+func NewServer(commClient CommunicationClient, fileReader FileReader, routesPath string, logger zerolog.Logger, opts ...Option) *Server {
+	s := &Server{
+		commClient:   commClient,
+		fileReader:   fileReader,
+		routesPath:   routesPath,
+		logger:       logger.With().Str("component", "prism_server").Logger(),
+		maxBodyBytes: communication.DefaultMaxBodyBytes,
+		timeout:      DefaultTimeout,
+		limiter:      newRateLimiter(),
+		cache:        newResponseCache(DefaultCacheBytes),
+		conns:        newConnectionHub(),
+		targets:      newTargetCounter(),
+		metrics:      newMetrics(),
+		apiTitle:     DefaultAPITitle,
+		apiVersion:   DefaultAPIVersion,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.commClient = &meteredClient{CommunicationClient: commClient, errors: s.metrics.upstreamErrors}
+	s.routes.Store(&routeTable{})
+	return s
+}
+
 func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.handleAction)
-	return mux
+	mux.HandleFunc("GET /_invocations/{id}", s.handleGetInvocation)
+	mux.HandleFunc("GET /_openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	return s.withAccessLog(mux)
 }
 
 func (s *Server) handleAction(w http.ResponseWriter, r *http.Request) {
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, "Failed to read body", http.StatusBadRequest)
-		return
-	}
+	start := time.Now()
+	rec := &responseRecorder{ResponseWriter: w}
+	w = rec
+	bodyReader := &countingReader{ReadCloser: r.Body}
+	r.Body = bodyReader
+	route, action := unmatchedRoute, ""
+	defer func() {
+		elapsed := time.Since(start)
+		s.metrics.observe(route, r.Method, rec, bodyReader.n, elapsed)
+		setAccessRoute(r.Context(), route, action)
+	}()
 
 	defer func() {
 		if err := r.Body.Close(); err != nil {
@@ -67,116 +212,242 @@ func (s *Server) handleAction(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	if r.URL.Path == "/" {
-		http.Error(w, "No action specified", http.StatusBadRequest)
+	routes := s.routes.Load()
+	if isPreflight(r) {
+		s.handlePreflight(w, routes, r)
+		return
+	}
+	if r.Method == http.MethodOptions {
+		s.handleOptions(w, r, routes)
 		return
 	}
 
-	action := s.extractAction(r.URL.Path)
-	log.Debug().Msgf("Received action: %s", action)
-
-	result, err := s.processAction(r.Context(), action, string(body), r.Method)
+	rt, params, err := routes.lookup(r.Method, r.URL.Path)
 	if err != nil {
-		s.handleError(w, err)
+		s.handleError(w, r, err)
+		return
+	}
+	s.log(r.Context()).Debug().Msgf("Matched %s %s to action: %s", r.Method, rt.template.raw, rt.config.Action)
+	route = rt.template.raw
+	inFlight := s.metrics.inFlight.WithLabelValues(route)
+	inFlight.Inc()
+	defer inFlight.Dec()
+	applyCORS(w, r, rt.config.CORS)
+
+	identity, err := s.authenticate(r, rt)
+	if err != nil {
+		s.handleError(w, r, err)
 		return
 	}
 
-	// Send response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	if err := s.checkRateLimit(w, r, rt, identity); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
 
-	_, err = w.Write([]byte(result))
+	if rt.config.WebSocket != nil {
+		action = rt.config.Action
+		s.serveWebSocket(w, r, rt, params, identity)
+		return
+	}
+
+	action = rt.pickTarget(r)
+	if len(rt.config.Targets) > 0 {
+		w.Header().Set(targetHeader, action)
+		defer func() {
+			s.targets.record(route, action, rec.status)
+			s.metrics.targets.WithLabelValues(route, action, statusLabel(rec.status)).Inc()
+		}()
+	}
+
+	async, err := invokeAsync(r, rt.config)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("Failed to write response")
-		http.Error(w, "Failed to write response", http.StatusInternalServerError)
+		s.handleError(w, r, err)
 		return
 	}
-}
 
-// extractAction converts URL path to action name
-func (s *Server) extractAction(path string) string {
-	action := path[1:] // Remove leading "/"
-	return strings.ReplaceAll(action, "/", ".")
-}
+	if rt.config.Cache != nil && !async && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+		s.serveCached(w, r, rt, action, params, identity)
+		return
+	}
 
-func (s *Server) processAction(ctx context.Context, action, body, method string) (string, error) {
-	cfg, err := s.loadRouteConfig(action)
+	body, err := s.readBody(w, r, rt.config)
 	if err != nil {
-		return "", err
+		s.handleError(w, r, err)
+		return
 	}
 
-	if cfg.Method != method {
-		return "", &HTTPError{
-			Code:    http.StatusMethodNotAllowed,
-			Message: "Method not allowed for action " + action,
-		}
+	if err := rt.validateBody(r.Method, body); err != nil {
+		s.handleError(w, r, err)
+		return
 	}
 
-	s.logger.Debug().Msgf("Processing action: %s with method: %s", action, method)
+	req := newExecuteRequest(r, rt.config, params, identity, body)
+	req.Action = action
+	if err := rt.mapRequest(req); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
 
-	resutl, err := s.commClient.SendAction(ctx, action, body)
+	if async {
+		s.startAsync(w, r, rt, req)
+		return
+	}
+
+	timeout := utils.Ternary(rt.config.Timeout > 0, rt.config.Timeout, s.timeout)
+	if rt.config.Stream {
+		s.streamAction(w, r, req, timeout)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	result, err := s.processAction(ctx, req)
 	if err != nil {
-		return "", &HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: "Error processing action: " + err.Error(),
-		}
+		s.handleError(w, r, err)
+		return
 	}
 
-	return resutl, nil
+	if err := rt.mapResponse(req, result); err != nil {
+		s.handleError(w, r, err)
+		return
+	}
+
+	s.writeResponse(w, r, result)
 }
 
-// loadRouteConfig loads and parses route configuration from file
-func (s *Server) loadRouteConfig(action string) (*RouteConfig, error) {
-	filePath := fmt.Sprintf("%s/%s.yml", s.routesPath, action)
+// readBody reads the request body, failing with 413 as soon as it exceeds the route's limit.
+func (s *Server) readBody(w http.ResponseWriter, r *http.Request, cfg *RouteConfig) ([]byte, error) {
+	limit := utils.Ternary(cfg.MaxBodyBytes > 0, cfg.MaxBodyBytes, s.maxBodyBytes)
+	tooLarge := &HTTPError{
+		Code:    http.StatusRequestEntityTooLarge,
+		Message: fmt.Sprintf("Request body exceeds the limit of %d bytes", limit),
+	}
 
-	if !s.fileReader.FileExists(filePath) {
-		return nil, &HTTPError{
-			Code:    http.StatusNotFound,
-			Message: "Action " + action + " not found",
-		}
+	if r.ContentLength > limit {
+		return nil, tooLarge
 	}
 
-	data, err := s.fileReader.ReadFile(filePath)
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, tooLarge
+		}
 		return nil, &HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: "Error reading action file: " + err.Error(),
+			Code:    http.StatusBadRequest,
+			Message: "Failed to read body",
 		}
 	}
+	return body, nil
+}
+
+// handleOptions answers OPTIONS requests with the methods served on the path of r.
+func (s *Server) handleOptions(w http.ResponseWriter, r *http.Request, routes *routeTable) {
+	path := r.URL.Path
+	allow := routes.allow(path)
+	if allow == nil {
+		s.handleError(w, r, &HTTPError{
+			Code:    http.StatusNotFound,
+			Message: "No route found for path " + path,
+		})
+		return
+	}
+
+	w.Header().Set("Allow", strings.Join(allow, ", "))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) processAction(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+	s.log(ctx).Debug().Msgf("Processing action: %s with method: %s", req.GetAction(), req.GetHttp().GetMethod())
 
-	cfg, err := loadFromYaml(data)
+	resutl, err := s.commClient.SendAction(ctx, req)
 	if err != nil {
-		return nil, &HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: "Error parsing action file: " + err.Error(),
-		}
+		return nil, actionError(req.GetAction(), err)
 	}
 
-	if err := cfg.Validate(); err != nil {
-		return nil, &HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: "Error validating action config: " + err.Error(),
+	return resutl, nil
+}
+
+// actionError maps a failed invocation to the HTTP error sent to the client.
+// Errors raised by the function carry an ErrorDetail with their own status;
+// other failures are mapped from their gRPC status code.
+func actionError(action string, err error) *HTTPError {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &HTTPError{Code: http.StatusGatewayTimeout, Message: "Action " + action + " timed out"}
+	}
+
+	st := status.Convert(err)
+	for _, detail := range st.Details() {
+		if d, ok := detail.(*pb.ErrorDetail); ok {
+			code := int(d.GetHttpStatus())
+			if code < 400 || code > 599 {
+				code = http.StatusInternalServerError
+			}
+			return &HTTPError{Code: code, Message: d.GetMessage(), ErrorCode: d.GetCode()}
 		}
 	}
 
-	return cfg, nil
+	switch st.Code() {
+	case codes.NotFound:
+		return &HTTPError{Code: http.StatusNotFound, Message: "Unknown action " + action}
+	case codes.Unavailable:
+		return &HTTPError{Code: http.StatusServiceUnavailable, Message: "Action " + action + " is unavailable"}
+	case codes.DeadlineExceeded:
+		return &HTTPError{Code: http.StatusGatewayTimeout, Message: "Action " + action + " timed out"}
+	case codes.InvalidArgument:
+		return &HTTPError{Code: http.StatusBadRequest, Message: st.Message()}
+	default:
+		// The error may name internal addresses or Docker failures, so it is only logged
+		return &HTTPError{Code: http.StatusBadGateway, Message: "Upstream error", Err: err}
+	}
 }
 
 type HTTPError struct {
-	Code    int
-	Message string
+	Code       int
+	Message    string
+	ErrorCode  string      // Optional: machine-readable code, sent as a JSON error body
+	Header     http.Header // Optional: headers to add to the error response
+	Violations []Violation // Optional: schema violations, sent with the JSON error body
+	Err        error       // Optional: the cause, logged but not sent to the client
 }
 
 func (e *HTTPError) Error() string {
 	return e.Message
 }
 
-func (s *Server) handleError(w http.ResponseWriter, err error) {
+func (s *Server) handleError(w http.ResponseWriter, r *http.Request, err error) {
 	if httpErr, ok := err.(*HTTPError); ok {
+		if httpErr.Err != nil {
+			s.log(r.Context()).Error().Err(httpErr.Err).Msg(httpErr.Message)
+		}
+		for key, values := range httpErr.Header {
+			w.Header()[key] = values
+		}
+		if httpErr.ErrorCode != "" {
+			writeJSONError(w, httpErr)
+			return
+		}
 		http.Error(w, httpErr.Message, httpErr.Code)
 		return
 	}
 
-	s.logger.Error().Err(err).Msg("Internal server error")
+	s.log(r.Context()).Error().Err(err).Msg("Internal server error")
 	http.Error(w, err.Error(), http.StatusInternalServerError)
 }
+
+// writeJSONError writes err as {"code": ..., "message": ...}.
+func writeJSONError(w http.ResponseWriter, err *HTTPError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(err.Code)
+	body := struct {
+		Code       string      `json:"code"`
+		Message    string      `json:"message"`
+		Violations []Violation `json:"violations,omitempty"`
+	}{err.ErrorCode, err.Message, err.Violations}
+	if encodeErr := json.NewEncoder(w).Encode(body); encodeErr != nil {
+		fmt.Fprintf(os.Stderr, "error writing error response: %v\n", encodeErr)
+	}
+}
@@ -1,31 +1,71 @@
 package prism
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
 	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Mock implementations for testing
 type MockCommunicationClient struct {
-	SendActionFunc func(ctx context.Context, action, body string) (string, error)
+	SendActionFunc       func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error)
+	SendActionStreamFunc func(ctx context.Context, req *commpb.ExecuteRequest, send func(*commpb.ExecuteChunk) error) error
+	SendActionAsyncFunc  func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.AsyncInvocation, error)
+	GetInvocationFunc    func(ctx context.Context, id string) (*commpb.AsyncInvocation, error)
+	HealthFunc           func(ctx context.Context) error
 }
 
-func (m *MockCommunicationClient) SendAction(ctx context.Context, action, body string) (string, error) {
+func (m *MockCommunicationClient) SendAction(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
 	if m.SendActionFunc != nil {
-		return m.SendActionFunc(ctx, action, body)
+		return m.SendActionFunc(ctx, req)
 	}
-	return `{"result": "success"}`, nil
+	return &commpb.ExecuteResponse{Resp: []byte(`{"result": "success"}`)}, nil
+}
+
+func (m *MockCommunicationClient) SendActionStream(ctx context.Context, req *commpb.ExecuteRequest, send func(*commpb.ExecuteChunk) error) error {
+	if m.SendActionStreamFunc != nil {
+		return m.SendActionStreamFunc(ctx, req, send)
+	}
+	return send(&commpb.ExecuteChunk{Data: []byte(`{"result": "success"}`)})
+}
+
+func (m *MockCommunicationClient) SendActionAsync(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.AsyncInvocation, error) {
+	if m.SendActionAsyncFunc != nil {
+		return m.SendActionAsyncFunc(ctx, req)
+	}
+	return &commpb.AsyncInvocation{Id: "test-invocation", Action: req.GetAction(), State: commpb.AsyncInvocation_RUNNING}, nil
+}
+
+func (m *MockCommunicationClient) GetInvocation(ctx context.Context, id string) (*commpb.AsyncInvocation, error) {
+	if m.GetInvocationFunc != nil {
+		return m.GetInvocationFunc(ctx, id)
+	}
+	return nil, status.Error(codes.NotFound, "unknown invocation")
+}
+
+func (m *MockCommunicationClient) Health(ctx context.Context) error {
+	if m.HealthFunc != nil {
+		return m.HealthFunc(ctx)
+	}
+	return nil
 }
 
 type MockFileReader struct {
-	ReadFileFunc   func(filename string) ([]byte, error)
-	FileExistsFunc func(filename string) bool
+	ReadFileFunc  func(filename string) ([]byte, error)
+	ListFilesFunc func(dirname string) ([]string, error)
 }
 
 func (m *MockFileReader) ReadFile(filename string) ([]byte, error) {
@@ -36,28 +76,59 @@ func (m *MockFileReader) ReadFile(filename string) ([]byte, error) {
 action: test.action`), nil
 }
 
-func (m *MockFileReader) FileExists(filename string) bool {
-	if m.FileExistsFunc != nil {
-		return m.FileExistsFunc(filename)
+func (m *MockFileReader) ListFiles(dirname string) ([]string, error) {
+	if m.ListFilesFunc != nil {
+		return m.ListFilesFunc(dirname)
+	}
+	return []string{"test.action.yml"}, nil
+}
+
+// routeFiles returns a MockFileReader serving the given route files by name.
+func routeFiles(files map[string]string) *MockFileReader {
+	return &MockFileReader{
+		ListFilesFunc: func(dirname string) ([]string, error) {
+			names := make([]string, 0, len(files))
+			for name := range files {
+				names = append(names, name)
+			}
+			return names, nil
+		},
+		ReadFileFunc: func(filename string) ([]byte, error) {
+			data, ok := files[filepath.Base(filename)]
+			if !ok {
+				return nil, errors.New("file not found")
+			}
+			return []byte(data), nil
+		},
+	}
+}
+
+// newTestServer creates a server with its route table loaded from fileReader.
+func newTestServer(t *testing.T, commClient CommunicationClient, fileReader FileReader, opts ...Option) *Server {
+	t.Helper()
+
+	server := NewServer(commClient, fileReader, "/test/routes", zerolog.Nop(), opts...)
+	if err := server.ReloadRoutes(); err != nil {
+		t.Fatalf("Failed to load routes: %v", err)
 	}
-	return true
+	return server
 }
 
 func TestServer_HandleAction_Success(t *testing.T) {
 	commClient := &MockCommunicationClient{
-		SendActionFunc: func(ctx context.Context, action, body string) (string, error) {
-			if action != "test.action" {
-				t.Errorf("Expected action 'test.action', got '%s'", action)
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			if req.GetAction() != "test.action" {
+				t.Errorf("Expected action 'test.action', got '%s'", req.GetAction())
 			}
-			if body != "test body" {
-				t.Errorf("Expected body 'test body', got '%s'", body)
+			if string(req.GetBody()) != "test body" {
+				t.Errorf("Expected body 'test body', got '%s'", req.GetBody())
 			}
-			return `{"result": "success"}`, nil
+			return &commpb.ExecuteResponse{Resp: []byte(`{"result": "success"}`)}, nil
 		},
 	}
 
 	fileReader := &MockFileReader{}
-	server := NewServer(commClient, fileReader, "/test/routes", zerolog.Nop())
+	server := newTestServer(t, commClient, fileReader)
 
 	req := httptest.NewRequest("POST", "/test/action", strings.NewReader("test body"))
 	w := httptest.NewRecorder()
@@ -74,29 +145,29 @@ func TestServer_HandleAction_Success(t *testing.T) {
 	}
 }
 
-func TestServer_HandleAction_NoAction(t *testing.T) {
+func TestServer_HandleAction_NoRoute(t *testing.T) {
 	commClient := &MockCommunicationClient{}
 	fileReader := &MockFileReader{}
-	server := NewServer(commClient, fileReader, "/test/routes", zerolog.Nop())
+	server := newTestServer(t, commClient, fileReader)
 
 	req := httptest.NewRequest("POST", "/", nil)
 	w := httptest.NewRecorder()
 
 	server.handleAction(w, req)
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
 	}
 }
 
 func TestServer_HandleAction_ActionNotFound(t *testing.T) {
 	commClient := &MockCommunicationClient{}
 	fileReader := &MockFileReader{
-		FileExistsFunc: func(filename string) bool {
-			return false
+		ListFilesFunc: func(dirname string) ([]string, error) {
+			return nil, nil
 		},
 	}
-	server := NewServer(commClient, fileReader, "/test/routes", zerolog.Nop())
+	server := newTestServer(t, commClient, fileReader)
 
 	req := httptest.NewRequest("POST", "/nonexistent/action", nil)
 	w := httptest.NewRecorder()
@@ -108,86 +179,510 @@ func TestServer_HandleAction_ActionNotFound(t *testing.T) {
 	}
 }
 
+func TestServer_HandleAction_MethodNotAllowed(t *testing.T) {
+	commClient := &MockCommunicationClient{}
+	fileReader := &MockFileReader{}
+	server := newTestServer(t, commClient, fileReader)
+
+	req := httptest.NewRequest("GET", "/test/action", nil)
+	w := httptest.NewRecorder()
+
+	server.handleAction(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status code %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+	if allow := w.Header().Get("Allow"); allow != "POST, OPTIONS" {
+		t.Errorf("Expected Allow 'POST, OPTIONS', got '%s'", allow)
+	}
+}
+
+func TestServer_HandleAction_ForwardsRequest(t *testing.T) {
+	var got *pb.HttpRequest
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			got = req.GetHttp()
+			return &commpb.ExecuteResponse{Resp: []byte(`{}`)}, nil
+		},
+	}
+	fileReader := routeFiles(map[string]string{
+		"users.yml": "path: /users/{id}\nmethod: POST\naction: users",
+	})
+	server := newTestServer(t, commClient, fileReader)
+
+	req := httptest.NewRequest("POST", "http://api.example.com/users/42?page=2", strings.NewReader("body"))
+	req.Header.Set("X-Custom", "value")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	w := httptest.NewRecorder()
+
+	server.handleAction(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if got.GetMethod() != "POST" || got.GetPath() != "/users/42" || got.GetRawQuery() != "page=2" {
+		t.Errorf("Unexpected request line: %s %s?%s", got.GetMethod(), got.GetPath(), got.GetRawQuery())
+	}
+	if got.GetHost() != "api.example.com" {
+		t.Errorf("Expected host 'api.example.com', got '%s'", got.GetHost())
+	}
+	if got.GetRemoteAddr() != req.RemoteAddr {
+		t.Errorf("Expected remote address '%s', got '%s'", req.RemoteAddr, got.GetRemoteAddr())
+	}
+	if values := got.GetHeaders()["X-Custom"].GetValues(); len(values) != 1 || values[0] != "value" {
+		t.Errorf("Expected header X-Custom=value, got %v", values)
+	}
+	if values := got.GetHeaders()["Cookie"].GetValues(); len(values) != 1 || values[0] != "session=abc" {
+		t.Errorf("Expected cookie header, got %v", values)
+	}
+	if got.GetPathParams()["id"] != "42" {
+		t.Errorf("Expected path param id=42, got %v", got.GetPathParams())
+	}
+}
+
+func TestServer_HandleAction_Binary(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\xff\xfe")
+
+	var got *commpb.ExecuteRequest
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			got = req
+			return &commpb.ExecuteResponse{Resp: req.GetBody(), ContentType: req.GetContentType()}, nil
+		},
+	}
+	server := newTestServer(t, commClient, &MockFileReader{})
+
+	req := httptest.NewRequest("POST", "/test/action", bytes.NewReader(png))
+	req.Header.Set("Content-Type", "image/png")
+	w := httptest.NewRecorder()
+
+	server.handleAction(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if !bytes.Equal(got.GetBody(), png) {
+		t.Errorf("Expected body %q to be forwarded, got %q", png, got.GetBody())
+	}
+	if got.GetContentType() != "image/png" {
+		t.Errorf("Expected content type 'image/png', got '%s'", got.GetContentType())
+	}
+	if !bytes.Equal(w.Body.Bytes(), png) {
+		t.Errorf("Expected response body %q, got %q", png, w.Body.Bytes())
+	}
+	if w.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("Expected Content-Type 'image/png', got '%s'", w.Header().Get("Content-Type"))
+	}
+}
+
+func TestServer_HandleAction_BodyTooLarge(t *testing.T) {
+	called := false
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			called = true
+			return &commpb.ExecuteResponse{}, nil
+		},
+	}
+	fileReader := routeFiles(map[string]string{
+		"small.yml":   "path: /small\nmethod: POST\naction: small\nmax_body_bytes: 4",
+		"default.yml": "path: /default\nmethod: POST\naction: default",
+	})
+	server := NewServer(commClient, fileReader, "/test/routes", zerolog.Nop(), WithMaxBodyBytes(8))
+	if err := server.ReloadRoutes(); err != nil {
+		t.Fatalf("Failed to load routes: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		// chunked hides the length so the limit is enforced while reading
+		chunked bool
+		code    int
+	}{
+		{name: "route limit", path: "/small", body: "12345", code: http.StatusRequestEntityTooLarge},
+		{name: "route limit while reading", path: "/small", body: "12345", chunked: true, code: http.StatusRequestEntityTooLarge},
+		{name: "within route limit", path: "/small", body: "1234", code: http.StatusOK},
+		{name: "default limit", path: "/default", body: "123456789", code: http.StatusRequestEntityTooLarge},
+		{name: "within default limit", path: "/default", body: "12345678", code: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called = false
+			req := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+
+			server.handleAction(w, req)
+
+			if w.Code != tt.code {
+				t.Errorf("Expected status code %d, got %d", tt.code, w.Code)
+			}
+			if called != (tt.code == http.StatusOK) {
+				t.Errorf("Expected action to be called: %v, got %v", tt.code == http.StatusOK, called)
+			}
+		})
+	}
+}
+
+func TestServer_HandleAction_MultipleMethods(t *testing.T) {
+	var gotMethod string
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			gotMethod = req.GetHttp().GetMethod()
+			return &commpb.ExecuteResponse{Resp: []byte(`{}`)}, nil
+		},
+	}
+	fileReader := routeFiles(map[string]string{
+		"items.yml": `path: /items
+methods: [GET, POST]
+action: items`,
+	})
+	server := newTestServer(t, commClient, fileReader)
+
+	for _, method := range []string{"GET", "POST"} {
+		req := httptest.NewRequest(method, "/items", nil)
+		w := httptest.NewRecorder()
+
+		server.handleAction(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected status code %d, got %d", method, http.StatusOK, w.Code)
+		}
+		if gotMethod != method {
+			t.Errorf("Expected method '%s' to be forwarded, got '%s'", method, gotMethod)
+		}
+	}
+}
+
+func TestServer_HandleAction_Head(t *testing.T) {
+	var gotMethod string
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			gotMethod = req.GetHttp().GetMethod()
+			return &commpb.ExecuteResponse{Resp: []byte(`{"result": "success"}`)}, nil
+		},
+	}
+	fileReader := routeFiles(map[string]string{
+		"items.yml": "path: /items\nmethod: GET\naction: items",
+	})
+	server := newTestServer(t, commClient, fileReader)
+
+	req := httptest.NewRequest("HEAD", "/items", nil)
+	w := httptest.NewRecorder()
+
+	server.handleAction(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if gotMethod != "GET" {
+		t.Errorf("Expected HEAD to invoke the function as GET, got '%s'", gotMethod)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected empty body, got '%s'", w.Body.String())
+	}
+	if cl := w.Header().Get("Content-Length"); cl != "21" {
+		t.Errorf("Expected Content-Length 21, got '%s'", cl)
+	}
+}
+
+func TestServer_HandleAction_Options(t *testing.T) {
+	fileReader := routeFiles(map[string]string{
+		"get.yml":  "path: /items/{id}\nmethod: GET\naction: items.get",
+		"edit.yml": "path: /items/{id}\nmethods: [PUT, DELETE]\naction: items.edit",
+	})
+	server := newTestServer(t, &MockCommunicationClient{}, fileReader)
+
+	req := httptest.NewRequest("OPTIONS", "/items/1", nil)
+	w := httptest.NewRecorder()
+
+	server.handleAction(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status code %d, got %d", http.StatusNoContent, w.Code)
+	}
+	expected := "GET, HEAD, PUT, DELETE, OPTIONS"
+	if allow := w.Header().Get("Allow"); allow != expected {
+		t.Errorf("Expected Allow '%s', got '%s'", expected, allow)
+	}
+
+	req = httptest.NewRequest("OPTIONS", "/missing", nil)
+	w = httptest.NewRecorder()
+
+	server.handleAction(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestServer_HandleAction_PathTemplate(t *testing.T) {
+	var gotAction string
+	var gotParams map[string]string
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			gotAction = req.GetAction()
+			gotParams = req.GetHttp().GetPathParams()
+			return &commpb.ExecuteResponse{Resp: []byte(`{}`)}, nil
+		},
+	}
+	fileReader := routeFiles(map[string]string{
+		"orders.yml": `path: /users/{id}/orders/{orderId}
+method: GET
+action: orders.get`,
+	})
+	server := newTestServer(t, commClient, fileReader)
+
+	req := httptest.NewRequest("GET", "/users/42/orders/7", nil)
+	w := httptest.NewRecorder()
+
+	server.handleAction(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if gotAction != "orders.get" {
+		t.Errorf("Expected action 'orders.get', got '%s'", gotAction)
+	}
+	if gotParams["id"] != "42" || gotParams["orderId"] != "7" {
+		t.Errorf("Expected params id=42 orderId=7, got %v", gotParams)
+	}
+}
+
 func TestServer_HandleAction_CommunicationError(t *testing.T) {
 	commClient := &MockCommunicationClient{
-		SendActionFunc: func(ctx context.Context, action, body string) (string, error) {
-			return "", errors.New("communication failed")
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			return nil, errors.New("communication failed")
 		},
 	}
 	fileReader := &MockFileReader{}
-	server := NewServer(commClient, fileReader, "/test/routes", zerolog.Nop())
+	server := newTestServer(t, commClient, fileReader)
 
 	req := httptest.NewRequest("POST", "/test/action", nil)
 	w := httptest.NewRecorder()
 
 	server.handleAction(w, req)
 
-	if w.Code != http.StatusInternalServerError {
-		t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, w.Code)
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadGateway, w.Code)
+	}
+	if strings.Contains(w.Body.String(), "communication failed") {
+		t.Errorf("Expected the upstream error not to reach the client, got '%s'", w.Body.String())
 	}
 }
 
-func TestServer_ExtractAction(t *testing.T) {
-	server := NewServer(nil, nil, "", zerolog.Nop())
+func TestServer_HandleAction_ErrorStatus(t *testing.T) {
+	userErr, _ := status.New(codes.FailedPrecondition, "order is closed").WithDetails(&pb.ErrorDetail{
+		HttpStatus: http.StatusConflict,
+		Code:       "order_closed",
+		Message:    "order is closed",
+	})
 
 	tests := []struct {
-		path     string
-		expected string
+		name string
+		err  error
+		code int
+		body string
 	}{
-		{"/test/action", "test.action"},
-		{"/user/create", "user.create"},
-		{"/api/v1/users", "api.v1.users"},
+		{name: "unknown action", err: status.Error(codes.NotFound, "unknown action: test.action"), code: http.StatusNotFound},
+		{name: "cold start failure", err: status.Error(codes.Unavailable, "failed to start container"), code: http.StatusServiceUnavailable},
+		{name: "timeout", err: status.Error(codes.DeadlineExceeded, "deadline exceeded"), code: http.StatusGatewayTimeout},
+		{name: "invalid payload", err: status.Error(codes.InvalidArgument, "failed to decode input"), code: http.StatusBadRequest},
+		{
+			name: "user error",
+			err:  fmt.Errorf("failed to send action to remote service: %w", userErr.Err()),
+			code: http.StatusConflict,
+			body: `{"code":"order_closed","message":"order is closed"}` + "\n",
+		},
+		{name: "unknown error", err: status.Error(codes.Unknown, "boom"), code: http.StatusBadGateway, body: "Upstream error\n"},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.path, func(t *testing.T) {
-			result := server.extractAction(tt.path)
-			if result != tt.expected {
-				t.Errorf("Expected '%s', got '%s'", tt.expected, result)
+		t.Run(tt.name, func(t *testing.T) {
+			commClient := &MockCommunicationClient{
+				SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+					return nil, tt.err
+				},
+			}
+			server := newTestServer(t, commClient, &MockFileReader{})
+
+			req := httptest.NewRequest("POST", "/test/action", nil)
+			w := httptest.NewRecorder()
+
+			server.handleAction(w, req)
+
+			if w.Code != tt.code {
+				t.Errorf("Expected status code %d, got %d", tt.code, w.Code)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("Expected body '%s', got '%s'", tt.body, w.Body.String())
 			}
 		})
 	}
 }
 
-func TestServer_LoadRouteConfig_Success(t *testing.T) {
-	fileReader := &MockFileReader{
-		ReadFileFunc: func(filename string) ([]byte, error) {
-			return []byte(`method: POST
-action: test.action`), nil
+func TestServer_HandleAction_Timeout(t *testing.T) {
+	var remaining time.Duration
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				t.Fatal("Expected context to carry a deadline")
+			}
+			remaining = time.Until(deadline)
+			<-ctx.Done()
+			return nil, status.Error(codes.DeadlineExceeded, "context deadline exceeded")
 		},
 	}
-	server := NewServer(nil, fileReader, "/test/routes", zerolog.Nop())
+	fileReader := routeFiles(map[string]string{
+		"slow.yml": "path: /slow\nmethod: GET\naction: slow\ntimeout: 10ms",
+	})
+	server := newTestServer(t, commClient, fileReader)
 
-	// Mock the routes.RouteConfig.Validate method behavior
-	// This assumes your RouteConfig has a working Validate method
-	cfg, err := server.loadRouteConfig("test.action")
-	if err != nil {
-		t.Errorf("Expected no error, got %v", err)
-	}
+	req := httptest.NewRequest("GET", "/slow", nil)
+	w := httptest.NewRecorder()
 
-	if cfg.Method != "POST" {
-		t.Errorf("Expected method 'POST', got '%s'", cfg.Method)
+	server.handleAction(w, req)
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected status code %d, got %d", http.StatusGatewayTimeout, w.Code)
+	}
+	if remaining > 10*time.Millisecond {
+		t.Errorf("Expected route timeout of 10ms, got deadline in %s", remaining)
 	}
 }
 
-func TestServer_LoadRouteConfig_FileNotFound(t *testing.T) {
-	fileReader := &MockFileReader{
-		FileExistsFunc: func(filename string) bool {
-			return false
+func TestServer_HandleAction_DefaultTimeout(t *testing.T) {
+	var remaining time.Duration
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			deadline, _ := ctx.Deadline()
+			remaining = time.Until(deadline)
+			return &commpb.ExecuteResponse{}, nil
 		},
 	}
+	server := NewServer(commClient, &MockFileReader{}, "/test/routes", zerolog.Nop(), WithTimeout(time.Minute))
+	if err := server.ReloadRoutes(); err != nil {
+		t.Fatalf("Failed to load routes: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/test/action", nil)
+	w := httptest.NewRecorder()
+
+	server.handleAction(w, req)
+
+	if remaining <= 30*time.Second || remaining > time.Minute {
+		t.Errorf("Expected default timeout of 1m, got deadline in %s", remaining)
+	}
+}
+
+func TestServer_ReloadRoutes_Success(t *testing.T) {
+	fileReader := routeFiles(map[string]string{
+		"a.yml":     "action: a\nmethod: GET",
+		"b.yaml":    "action: b\nmethod: POST\npath: /things/{id}",
+		"README.md": "not a route",
+	})
+	server := NewServer(nil, fileReader, "/test/routes", zerolog.Nop())
+
+	if err := server.ReloadRoutes(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if n := len(server.routes.Load().routes); n != 2 {
+		t.Errorf("Expected 2 routes, got %d", n)
+	}
+}
+
+func TestServer_ReloadRoutes_SkipsInvalidFiles(t *testing.T) {
+	fileReader := routeFiles(map[string]string{
+		"bad.yml":      "action: bad\nmethod: ERROR",
+		"broken.yml":   "action: [",
+		"conflict.yml": "action: other\nmethod: GET\npath: /good",
+		"good.yml":     "action: good\nmethod: GET\npath: /good",
+	})
 	server := NewServer(nil, fileReader, "/test/routes", zerolog.Nop())
 
-	_, err := server.loadRouteConfig("nonexistent")
-	if err == nil {
+	if err := server.ReloadRoutes(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	routes := server.routes.Load().routes
+	if len(routes) != 1 {
+		t.Fatalf("Expected 1 route, got %d", len(routes))
+	}
+	// conflict.yml sorts before good.yml, so it owns the path
+	if routes[0].config.Action != "other" {
+		t.Errorf("Expected action 'other', got '%s'", routes[0].config.Action)
+	}
+}
+
+func TestServer_ReloadRoutes_SwapsTable(t *testing.T) {
+	files := map[string]string{
+		"a.yml": "action: a\nmethod: GET\npath: /old",
+	}
+	server := newTestServer(t, &MockCommunicationClient{}, routeFiles(files))
+	before := server.routes.Load()
+
+	if err := server.ReloadRoutes(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if server.routes.Load() != before {
+		t.Error("Expected unchanged files to keep the current table")
+	}
+
+	files["a.yml"] = "action: a\nmethod: GET\npath: /new"
+	if err := server.ReloadRoutes(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/new", nil)
+	w := httptest.NewRecorder()
+	server.handleAction(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestServer_ReloadRoutes_ListError(t *testing.T) {
+	files := map[string]string{
+		"a.yml": "action: a\nmethod: GET",
+	}
+	fileReader := routeFiles(files)
+	server := newTestServer(t, &MockCommunicationClient{}, fileReader)
+
+	fileReader.ListFilesFunc = func(dirname string) ([]string, error) {
+		return nil, errors.New("directory gone")
+	}
+
+	if err := server.ReloadRoutes(); err == nil {
 		t.Error("Expected error, got nil")
 	}
+	if n := len(server.routes.Load().routes); n != 1 {
+		t.Errorf("Expected previous table with 1 route, got %d", n)
+	}
+}
 
-	if httpErr, ok := err.(*HTTPError); ok {
-		if httpErr.Code != http.StatusNotFound {
-			t.Errorf("Expected status code %d, got %d", http.StatusNotFound, httpErr.Code)
-		}
-	} else {
-		t.Errorf("Expected HTTPError, got %T", err)
+func TestParseRouteConfig(t *testing.T) {
+	cfg, err := parseRouteConfig([]byte(`method: POST
+action: test.action
+timeout: 1m30s`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if cfg.Method != "POST" {
+		t.Errorf("Expected method 'POST', got '%s'", cfg.Method)
+	}
+	if cfg.Timeout != 90*time.Second {
+		t.Errorf("Expected timeout 1m30s, got %s", cfg.Timeout)
+	}
+
+	if _, err := parseRouteConfig([]byte(`method: POST`)); err == nil {
+		t.Error("Expected validation error, got nil")
 	}
 }
@@ -0,0 +1,104 @@
+package prism
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	"github.com/Ow1Dev/NoctiFunc/pkg/communication"
+)
+
+// streamAction relays the function's output to the client chunk by chunk,
+// flushing each one as it arrives. timeout only bounds the wait for the first
+// chunk, so streams may run as long as the function keeps producing output.
+// Failures before the first chunk get a regular error response; later
+// failures abort the connection so the client cannot mistake a truncated
+// stream for a complete one.
+func (s *Server) streamAction(w http.ResponseWriter, r *http.Request, req *commpb.ExecuteRequest, timeout time.Duration) {
+	ctx, received, cancel := communication.WithFirstChunkTimeout(r.Context(), timeout)
+	defer cancel()
+	req.TimeoutMs = timeout.Milliseconds()
+
+	rc := http.NewResponseController(w)
+	started := false
+	writeBody := r.Method != http.MethodHead
+
+	err := s.commClient.SendActionStream(ctx, req, func(chunk *commpb.ExecuteChunk) error {
+		if !started {
+			received()
+			status, err := s.startStream(w, chunk)
+			if err != nil {
+				return err
+			}
+			started = true
+			writeBody = writeBody && bodyAllowed(status)
+		}
+
+		if writeBody && len(chunk.GetData()) > 0 {
+			if _, err := w.Write(chunk.GetData()); err != nil {
+				return err
+			}
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	})
+	if err == nil {
+		if !started {
+			w.WriteHeader(http.StatusOK)
+		}
+		return
+	}
+
+	var httpErr *HTTPError
+	switch {
+	case errors.As(err, &httpErr) && !started:
+		s.handleError(w, r, httpErr)
+	case !started && errors.Is(context.Cause(ctx), context.DeadlineExceeded):
+		s.handleError(w, r, actionError(req.GetAction(), context.DeadlineExceeded))
+	case !started:
+		s.handleError(w, r, actionError(req.GetAction(), err))
+	default:
+		s.log(ctx).Error().Err(err).Msgf("Stream of action %s failed", req.GetAction())
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// startStream writes the status and headers the function chose for a stream
+// and returns the status.
+func (s *Server) startStream(w http.ResponseWriter, chunk *commpb.ExecuteChunk) (int, error) {
+	status := http.StatusOK
+	if code := int(chunk.GetHttp().GetStatusCode()); code != 0 {
+		if code < 200 || code > 599 {
+			return 0, &HTTPError{
+				Code:    http.StatusBadGateway,
+				Message: "Function returned invalid status code " + strconv.Itoa(code),
+			}
+		}
+		status = code
+	}
+
+	header := w.Header()
+	for key, values := range responseHeader(&commpb.ExecuteResponse{Http: chunk.GetHttp()}) {
+		header[key] = values
+	}
+	if header.Get("Content-Type") == "" && chunk.GetContentType() != "" {
+		header.Set("Content-Type", chunk.GetContentType())
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type")); mediaType == "text/event-stream" {
+		if header.Get("Cache-Control") == "" {
+			header.Set("Cache-Control", "no-cache")
+		}
+		// Keep reverse proxies such as nginx from buffering the events
+		header.Set("X-Accel-Buffering", "no")
+	}
+
+	w.WriteHeader(status)
+	return status, nil
+}
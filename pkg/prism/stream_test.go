@@ -0,0 +1,174 @@
+package prism
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServer_HandleAction_Stream(t *testing.T) {
+	received := make(chan struct{})
+	commClient := &MockCommunicationClient{
+		SendActionStreamFunc: func(ctx context.Context, req *commpb.ExecuteRequest, send func(*commpb.ExecuteChunk) error) error {
+			if err := send(&commpb.ExecuteChunk{Data: []byte("data: one\n\n"), ContentType: "text/event-stream"}); err != nil {
+				return err
+			}
+			// The second event is only produced once the client has read the first
+			<-received
+			return send(&commpb.ExecuteChunk{Data: []byte("data: two\n\n")})
+		},
+	}
+	server := newTestServer(t, commClient, routeFiles(map[string]string{
+		"events.yml": "path: /events\nmethod: GET\naction: events\nstream: true",
+	}))
+
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("Expected Content-Type 'text/event-stream', got '%s'", resp.Header.Get("Content-Type"))
+	}
+	if resp.Header.Get("Cache-Control") != "no-cache" {
+		t.Errorf("Expected Cache-Control 'no-cache', got '%s'", resp.Header.Get("Cache-Control"))
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	for i, want := range []string{"data: one\n", "\n", "data: two\n", "\n"} {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read line %d: %v", i, err)
+		}
+		if line != want {
+			t.Errorf("Expected line %q, got %q", want, line)
+		}
+		if i == 1 {
+			close(received)
+		}
+	}
+}
+
+func TestServer_HandleAction_StreamStatus(t *testing.T) {
+	commClient := &MockCommunicationClient{
+		SendActionStreamFunc: func(ctx context.Context, req *commpb.ExecuteRequest, send func(*commpb.ExecuteChunk) error) error {
+			return send(&commpb.ExecuteChunk{
+				Data: []byte("partial"),
+				Http: &pb.HttpResponse{StatusCode: http.StatusPartialContent, Headers: map[string]*pb.HeaderValues{
+					"X-Progress": {Values: []string{"50"}},
+				}},
+			})
+		},
+	}
+	server := newTestServer(t, commClient, routeFiles(map[string]string{
+		"events.yml": "path: /events\nmethod: GET\naction: events\nstream: true",
+	}))
+
+	w := httptest.NewRecorder()
+	server.handleAction(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if w.Code != http.StatusPartialContent {
+		t.Errorf("Expected status code %d, got %d", http.StatusPartialContent, w.Code)
+	}
+	if w.Header().Get("X-Progress") != "50" {
+		t.Errorf("Expected X-Progress '50', got '%s'", w.Header().Get("X-Progress"))
+	}
+	if w.Body.String() != "partial" {
+		t.Errorf("Expected body 'partial', got '%s'", w.Body.String())
+	}
+	if !w.Flushed {
+		t.Error("Expected the response to be flushed")
+	}
+}
+
+func TestServer_HandleAction_StreamError(t *testing.T) {
+	commClient := &MockCommunicationClient{
+		SendActionStreamFunc: func(ctx context.Context, req *commpb.ExecuteRequest, send func(*commpb.ExecuteChunk) error) error {
+			return status.Error(codes.NotFound, "unknown action")
+		},
+	}
+	server := newTestServer(t, commClient, routeFiles(map[string]string{
+		"events.yml": "path: /events\nmethod: GET\naction: events\nstream: true",
+	}))
+
+	w := httptest.NewRecorder()
+	server.handleAction(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Unknown action events") {
+		t.Errorf("Expected unknown action error, got '%s'", w.Body.String())
+	}
+}
+
+func TestServer_HandleAction_StreamOutlivesTimeout(t *testing.T) {
+	var timeoutMs int64
+	commClient := &MockCommunicationClient{
+		SendActionStreamFunc: func(ctx context.Context, req *commpb.ExecuteRequest, send func(*commpb.ExecuteChunk) error) error {
+			timeoutMs = req.GetTimeoutMs()
+			for _, data := range []string{"one ", "two ", "three"} {
+				if err := send(&commpb.ExecuteChunk{Data: []byte(data)}); err != nil {
+					return err
+				}
+				// Together the pauses outlast the route's timeout
+				select {
+				case <-time.After(40 * time.Millisecond):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		},
+	}
+	server := newTestServer(t, commClient, routeFiles(map[string]string{
+		"events.yml": "path: /events\nmethod: GET\naction: events\nstream: true\ntimeout: 50ms",
+	}))
+
+	w := httptest.NewRecorder()
+	server.handleAction(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if w.Body.String() != "one two three" {
+		t.Errorf("Expected body 'one two three', got '%s'", w.Body.String())
+	}
+	if timeoutMs != 50 {
+		t.Errorf("Expected first chunk timeout 50ms, got %dms", timeoutMs)
+	}
+}
+
+func TestServer_HandleAction_StreamFirstChunkTimeout(t *testing.T) {
+	commClient := &MockCommunicationClient{
+		SendActionStreamFunc: func(ctx context.Context, req *commpb.ExecuteRequest, send func(*commpb.ExecuteChunk) error) error {
+			<-ctx.Done()
+			return status.FromContextError(ctx.Err()).Err()
+		},
+	}
+	server := newTestServer(t, commClient, routeFiles(map[string]string{
+		"events.yml": "path: /events\nmethod: GET\naction: events\nstream: true\ntimeout: 20ms",
+	}))
+
+	w := httptest.NewRecorder()
+	server.handleAction(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected status code %d, got %d", http.StatusGatewayTimeout, w.Code)
+	}
+}
@@ -0,0 +1,128 @@
+package prism
+
+import (
+	"cmp"
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// targetHeader reports which target served a request on routes with targets.
+const targetHeader = "X-Target-Action"
+
+// pickTarget chooses the action that serves r. Routes without targets
+// always invoke their own action.
+func (rt *route) pickTarget(r *http.Request) string {
+	targets := rt.config.Targets
+	if len(targets) == 0 {
+		return rt.config.Action
+	}
+
+	total := 0
+	for _, t := range targets {
+		total += t.Weight
+	}
+
+	var n int
+	if key := stickyKey(r, rt.config.Sticky); key != "" {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		n = int(h.Sum64() % uint64(total))
+	} else {
+		n = rand.IntN(total)
+	}
+
+	for _, t := range targets {
+		if n < t.Weight {
+			return t.Action
+		}
+		n -= t.Weight
+	}
+	// Unreachable while the weights sum to total
+	return targets[len(targets)-1].Action
+}
+
+// stickyKey returns the value r is assigned a target by, or "" to assign at random.
+func stickyKey(r *http.Request, cfg *StickyConfig) string {
+	switch {
+	case cfg == nil:
+		return ""
+	case cfg.Header != "":
+		return r.Header.Get(cfg.Header)
+	default:
+		cookie, err := r.Cookie(cfg.Cookie)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+	}
+}
+
+// TargetStats counts the requests a target of a route has served, so error
+// rates can be compared before a target is promoted.
+type TargetStats struct {
+	Route    string `json:"route"`
+	Action   string `json:"action"`
+	Requests int64  `json:"requests"`
+	Errors   int64  `json:"errors"` // responses with a 5xx status
+}
+
+type targetKey struct {
+	route  string
+	action string
+}
+
+// targetCounter collects TargetStats for routes with targets.
+type targetCounter struct {
+	mu     sync.Mutex
+	counts map[targetKey]*TargetStats
+}
+
+func newTargetCounter() *targetCounter {
+	return &targetCounter{counts: make(map[targetKey]*TargetStats)}
+}
+
+func (c *targetCounter) record(route, action string, status int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := targetKey{route: route, action: action}
+	stats, ok := c.counts[key]
+	if !ok {
+		stats = &TargetStats{Route: route, Action: action}
+		c.counts[key] = stats
+	}
+	stats.Requests++
+	if status >= 500 {
+		stats.Errors++
+	}
+}
+
+// snapshot returns the counts ordered by route and action.
+func (c *targetCounter) snapshot() []TargetStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make([]TargetStats, 0, len(c.counts))
+	for _, stats := range c.counts {
+		out = append(out, *stats)
+	}
+	slices.SortFunc(out, func(a, b TargetStats) int {
+		return cmp.Or(strings.Compare(a.Route, b.Route), strings.Compare(a.Action, b.Action))
+	})
+	return out
+}
+
+// TargetStats returns the requests served by each target of routes with
+// targets since the server started.
+func (s *Server) TargetStats() []TargetStats {
+	return s.targets.snapshot()
+}
+
+// handleTargetStats reports TargetStats to admins.
+func (s *Server) handleTargetStats(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, r, http.StatusOK, map[string][]TargetStats{"targets": s.TargetStats()})
+}
@@ -0,0 +1,157 @@
+package prism
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+)
+
+const canaryRoute = `path: /orders
+method: GET
+action: orders
+targets:
+  - action: orders.v1
+    weight: 3
+  - action: orders.v2
+    weight: 1
+  - action: orders.v3
+    weight: 0
+sticky:
+  header: X-User`
+
+// newTargetServer serves canaryRoute, failing every invocation of orders.v2.
+func newTargetServer(t *testing.T) (*Server, map[string]int) {
+	t.Helper()
+
+	invoked := make(map[string]int)
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			invoked[req.GetAction()]++
+			resp := &commpb.ExecuteResponse{Resp: []byte(`{}`)}
+			if req.GetAction() == "orders.v2" {
+				resp.Http = &pb.HttpResponse{StatusCode: http.StatusInternalServerError}
+			}
+			return resp, nil
+		},
+	}
+	server := newTestServer(t, commClient, routeFiles(map[string]string{"orders.yml": canaryRoute}), WithAdminToken("secret"))
+	return server, invoked
+}
+
+func TestServer_HandleAction_Targets(t *testing.T) {
+	server, invoked := newTargetServer(t)
+	handler := server.Handler()
+
+	const requests = 400
+	for range requests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+		target := w.Header().Get(targetHeader)
+		if target != "orders.v1" && target != "orders.v2" {
+			t.Fatalf("Expected %s to name orders.v1 or orders.v2, got '%s'", targetHeader, target)
+		}
+	}
+
+	if invoked["orders.v3"] != 0 {
+		t.Errorf("Expected no invocations of a target with weight 0, got %d", invoked["orders.v3"])
+	}
+	if n := invoked["orders.v2"]; n < requests/8 || n > requests*3/8 {
+		t.Errorf("Expected about a quarter of %d requests on orders.v2, got %d", requests, n)
+	}
+	if invoked["orders.v1"]+invoked["orders.v2"] != requests {
+		t.Errorf("Expected %d invocations, got %v", requests, invoked)
+	}
+}
+
+func TestServer_HandleAction_StickyTarget(t *testing.T) {
+	server, _ := newTargetServer(t)
+	handler := server.Handler()
+
+	seen := make(map[string]bool)
+	for i := range 50 {
+		user := fmt.Sprintf("user-%d", i)
+
+		var first string
+		for range 5 {
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			req.Header.Set("X-User", user)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			target := w.Header().Get(targetHeader)
+			if first == "" {
+				first = target
+			} else if target != first {
+				t.Fatalf("Expected %s to stay on %s, got %s", user, first, target)
+			}
+		}
+		seen[first] = true
+	}
+
+	if !seen["orders.v1"] || !seen["orders.v2"] {
+		t.Errorf("Expected users to be spread over both targets, got %v", seen)
+	}
+}
+
+func TestServer_TargetStats(t *testing.T) {
+	server, invoked := newTargetServer(t)
+	handler, admin := server.Handler(), server.AdminHandler()
+
+	for range 20 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	}
+
+	w := httptest.NewRecorder()
+	admin.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/_admin/targets", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d without token, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/_admin/targets", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code == http.StatusOK {
+		t.Error("Expected the public handler not to serve target stats")
+	}
+
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var body struct {
+		Targets []TargetStats `json:"targets"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	var total int64
+	for _, stats := range body.Targets {
+		total += stats.Requests
+		if stats.Route != "/orders" {
+			t.Errorf("Expected route /orders, got %s", stats.Route)
+		}
+		if stats.Requests != int64(invoked[stats.Action]) {
+			t.Errorf("Expected %d requests for %s, got %d", invoked[stats.Action], stats.Action, stats.Requests)
+		}
+		wantErrors := int64(0)
+		if stats.Action == "orders.v2" {
+			wantErrors = stats.Requests
+		}
+		if stats.Errors != wantErrors {
+			t.Errorf("Expected %d errors for %s, got %d", wantErrors, stats.Action, stats.Errors)
+		}
+	}
+	if total != 20 {
+		t.Errorf("Expected 20 requests in total, got %d", total)
+	}
+}
@@ -0,0 +1,310 @@
+package prism
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"github.com/Ow1Dev/NoctiFunc/pkg/communication"
+	"github.com/Ow1Dev/NoctiFunc/pkg/utils"
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WebSocket event types sent to functions.
+const (
+	WebSocketConnect    = "connect"
+	WebSocketMessage    = "message"
+	WebSocketDisconnect = "disconnect"
+)
+
+const (
+	// wsPongWait is how long a connection may stay silent before it is dropped.
+	wsPongWait = 60 * time.Second
+	// wsPingPeriod is shorter than wsPongWait so live clients answer in time.
+	wsPingPeriod = wsPongWait * 9 / 10
+	wsWriteWait  = 10 * time.Second
+)
+
+// wsConn is a client connection held for a websocket route.
+type wsConn struct {
+	id      string
+	conn    *websocket.Conn
+	writeMu sync.Mutex // gorilla/websocket allows one concurrent writer
+}
+
+func (c *wsConn) write(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return c.conn.WriteMessage(messageType, data)
+}
+
+// close sends a close frame and drops the connection, which ends its read loop.
+func (c *wsConn) close(code int, reason string) {
+	msg := websocket.FormatCloseMessage(code, reason)
+	_ = c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteWait))
+	_ = c.conn.Close()
+}
+
+// connectionHub tracks the open connections by ID.
+type connectionHub struct {
+	mu    sync.Mutex
+	conns map[string]*wsConn
+}
+
+func newConnectionHub() *connectionHub {
+	return &connectionHub{conns: make(map[string]*wsConn)}
+}
+
+func (h *connectionHub) add(c *wsConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.conns[c.id] = c
+}
+
+func (h *connectionHub) remove(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.conns, id)
+}
+
+func (h *connectionHub) get(id string) (*wsConn, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c, ok := h.conns[id]
+	return c, ok
+}
+
+func (h *connectionHub) closeAll(code int, reason string) {
+	h.mu.Lock()
+	conns := make([]*wsConn, 0, len(h.conns))
+	for _, c := range h.conns {
+		conns = append(conns, c)
+	}
+	h.mu.Unlock()
+
+	for _, c := range conns {
+		c.close(code, reason)
+	}
+}
+
+// CloseConnections closes every WebSocket connection, telling clients the
+// server is going away. http.Server.Shutdown does not close them on its own.
+func (s *Server) CloseConnections() {
+	s.conns.closeAll(websocket.CloseGoingAway, "server shutting down")
+}
+
+// serveWebSocket upgrades r and holds the connection until either side
+// closes it. The route's actions are invoked on connect, for each message
+// and after disconnect; messages are handled one at a time in order.
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request, rt *route, params map[string]string, identity *pb.Identity) {
+	cfg := rt.config
+	log := s.log(r.Context())
+	if !websocket.IsWebSocketUpgrade(r) {
+		s.handleError(w, r, &HTTPError{
+			Code:    http.StatusUpgradeRequired,
+			Message: "Route requires a WebSocket connection",
+			Header:  http.Header{"Upgrade": {"websocket"}, "Connection": {"Upgrade"}},
+		})
+		return
+	}
+	if !webSocketOriginAllowed(r, cfg.CORS) {
+		s.handleError(w, r, &HTTPError{Code: http.StatusForbidden, Message: "Origin not allowed"})
+		return
+	}
+
+	id := rand.Text()
+	event := func(action, typ string, payload []byte, binary bool) *commpb.ExecuteRequest {
+		req := newExecuteRequest(r, cfg, params, identity, payload)
+		req.Action = action
+		req.ContentType = ""
+		if typ == WebSocketMessage {
+			req.ContentType = utils.Ternary(binary, "application/octet-stream", "text/plain; charset=utf-8")
+		}
+		req.Http.Websocket = &pb.WebSocketEvent{ConnectionId: id, Type: typ, Binary: binary}
+		return req
+	}
+
+	upgradeHeader := http.Header{}
+	if cfg.WebSocket.Connect != "" {
+		resp, err := s.invokeWebSocket(r.Context(), cfg, event(cfg.WebSocket.Connect, WebSocketConnect, nil, false))
+		if err != nil {
+			s.handleError(w, r, err)
+			return
+		}
+		if code := resp.GetHttp().GetStatusCode(); code != 0 && (code < 200 || code > 299) {
+			s.writeResponse(w, r, resp)
+			return
+		}
+		upgradeHeader = webSocketUpgradeHeader(resp)
+	}
+	// The upgrade response is written by websocket.Upgrader, which ignores w.Header()
+	upgradeHeader.Set(communication.RequestIDHeader, r.Header.Get(communication.RequestIDHeader))
+
+	disconnect := func() {
+		if cfg.WebSocket.Disconnect == "" {
+			return
+		}
+		if _, err := s.invokeWebSocket(r.Context(), cfg, event(cfg.WebSocket.Disconnect, WebSocketDisconnect, nil, false)); err != nil {
+			log.Warn().Err(err).Msgf("Disconnect action %s failed for connection %s", cfg.WebSocket.Disconnect, id)
+		}
+	}
+
+	upgrader := websocket.Upgrader{
+		// The origin was checked against the route's CORS settings above
+		CheckOrigin: func(*http.Request) bool { return true },
+	}
+	conn, err := upgrader.Upgrade(w, r, upgradeHeader)
+	if err != nil {
+		// Upgrade has already replied with an error
+		log.Debug().Err(err).Msg("WebSocket upgrade failed")
+		disconnect()
+		return
+	}
+
+	c := &wsConn{id: id, conn: conn}
+	s.conns.add(c)
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		s.conns.remove(id)
+		_ = conn.Close()
+		disconnect()
+	}()
+	go c.keepAlive(done)
+
+	log.Debug().Msgf("WebSocket connection %s opened on %s", id, rt.template.raw)
+	conn.SetReadLimit(utils.Ternary(cfg.MaxBodyBytes > 0, cfg.MaxBodyBytes, s.maxBodyBytes))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Debug().Err(err).Msgf("WebSocket connection %s closed", id)
+			}
+			return
+		}
+
+		resp, err := s.invokeWebSocket(r.Context(), cfg, event(cfg.Action, WebSocketMessage, data, messageType == websocket.BinaryMessage))
+		if err != nil {
+			log.Warn().Err(err).Msgf("Message action %s failed for connection %s", cfg.Action, id)
+			continue
+		}
+		if reply := resp.GetResp(); len(reply) > 0 {
+			if err := c.write(utils.Ternary(utf8.Valid(reply), websocket.TextMessage, websocket.BinaryMessage), reply); err != nil {
+				log.Debug().Err(err).Msgf("Failed to reply on connection %s", id)
+				return
+			}
+		}
+	}
+}
+
+// invokeWebSocket runs one event of a websocket route within the route's timeout.
+func (s *Server) invokeWebSocket(ctx context.Context, cfg *RouteConfig, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, utils.Ternary(cfg.Timeout > 0, cfg.Timeout, s.timeout))
+	defer cancel()
+	return s.processAction(ctx, req)
+}
+
+// keepAlive pings the client until done is closed, so dead connections
+// fail their read deadline.
+func (c *wsConn) keepAlive(done <-chan struct{}) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// webSocketUpgradeHeader returns the headers of the connect action's response
+// that are sent with the upgrade: cookies and the chosen subprotocol.
+func webSocketUpgradeHeader(resp *commpb.ExecuteResponse) http.Header {
+	header := http.Header{}
+	for _, key := range []string{"Set-Cookie", "Sec-Websocket-Protocol"} {
+		if values := resp.GetHttp().GetHeaders()[key].GetValues(); len(values) > 0 {
+			header[key] = values
+		}
+	}
+	return header
+}
+
+// webSocketOriginAllowed reports whether a browser on r's origin may connect.
+// Routes with CORS settings accept their allowed origins; others only accept
+// their own host. Clients that send no Origin are not browsers and are allowed.
+func webSocketOriginAllowed(r *http.Request, cfg *CORSConfig) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if cfg != nil {
+		return cfg.allowsOrigin(origin)
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// ConnectionService returns the gRPC service igniterelay calls to send to the
+// WebSocket connections held by s.
+func (s *Server) ConnectionService() commpb.ConnectionServiceServer {
+	return &connectionService{conns: s.conns}
+}
+
+type connectionService struct {
+	commpb.UnimplementedConnectionServiceServer
+	conns *connectionHub
+}
+
+// PostToConnection sends a message to a connection.
+func (cs *connectionService) PostToConnection(_ context.Context, r *commpb.PostToConnectionRequest) (*commpb.PostToConnectionResponse, error) {
+	c, ok := cs.conns.get(r.GetConnectionId())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown connection: %s", r.GetConnectionId())
+	}
+	if !r.GetBinary() && !utf8.Valid(r.GetData()) {
+		return nil, status.Error(codes.InvalidArgument, "text messages must be valid UTF-8")
+	}
+
+	if err := c.write(utils.Ternary(r.GetBinary(), websocket.BinaryMessage, websocket.TextMessage), r.GetData()); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to write to connection %s: %v", r.GetConnectionId(), err)
+	}
+	return &commpb.PostToConnectionResponse{}, nil
+}
+
+// CloseConnection closes a connection with the given close code and reason.
+func (cs *connectionService) CloseConnection(_ context.Context, r *commpb.CloseConnectionRequest) (*commpb.CloseConnectionResponse, error) {
+	code := utils.Ternary(r.GetCode() != 0, int(r.GetCode()), websocket.CloseNormalClosure)
+	if code != websocket.CloseNormalClosure && (code < 3000 || code > 4999) {
+		return nil, status.Errorf(codes.InvalidArgument, "close code must be 1000 or between 3000 and 4999, got %d", code)
+	}
+	// Close frames hold at most 125 bytes, two of which are the code
+	if len(r.GetReason()) > 123 {
+		return nil, status.Error(codes.InvalidArgument, "close reason must be at most 123 bytes")
+	}
+
+	c, ok := cs.conns.get(r.GetConnectionId())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown connection: %s", r.GetConnectionId())
+	}
+	c.close(code, r.GetReason())
+	return &commpb.CloseConnectionResponse{}, nil
+}
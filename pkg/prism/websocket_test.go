@@ -0,0 +1,179 @@
+package prism
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const chatRoute = "path: /chat\nmethod: GET\naction: chat.message\nwebsocket:\n  connect: chat.connect\n  disconnect: chat.disconnect"
+
+func dialWebSocket(t *testing.T, ts *httptest.Server, path string) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+path, nil)
+	if conn != nil {
+		t.Cleanup(func() {
+			_ = conn.Close()
+		})
+	}
+	return conn, resp, err
+}
+
+func TestServer_WebSocket(t *testing.T) {
+	events := make(chan *pb.WebSocketEvent, 3)
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			events <- req.GetHttp().GetWebsocket()
+			if req.GetAction() == "chat.message" {
+				return &commpb.ExecuteResponse{Resp: append([]byte("echo: "), req.GetBody()...)}, nil
+			}
+			return &commpb.ExecuteResponse{}, nil
+		},
+	}
+	server := newTestServer(t, commClient, routeFiles(map[string]string{"chat.yml": chatRoute}))
+
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	conn, _, err := dialWebSocket(t, ts, "/chat")
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	connect := <-events
+	if connect.GetType() != WebSocketConnect || connect.GetConnectionId() == "" {
+		t.Fatalf("Expected connect event with a connection ID, got %v", connect)
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("hello")); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	_, reply, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("Failed to read reply: %v", err)
+	}
+	if string(reply) != "echo: hello" {
+		t.Errorf("Expected reply 'echo: hello', got '%s'", reply)
+	}
+	if message := <-events; message.GetType() != WebSocketMessage || message.GetConnectionId() != connect.GetConnectionId() {
+		t.Errorf("Expected message event on connection %s, got %v", connect.GetConnectionId(), message)
+	}
+
+	// Functions post to the connection through the connection service
+	svc := server.ConnectionService()
+	if _, err := svc.PostToConnection(context.Background(), &commpb.PostToConnectionRequest{
+		ConnectionId: connect.GetConnectionId(),
+		Data:         []byte{0x01, 0x02},
+		Binary:       true,
+	}); err != nil {
+		t.Fatalf("Failed to post to connection: %v", err)
+	}
+	messageType, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("Failed to read posted message: %v", err)
+	}
+	if messageType != websocket.BinaryMessage || len(data) != 2 {
+		t.Errorf("Expected 2 byte binary message, got type %d with %v", messageType, data)
+	}
+
+	if _, err := svc.CloseConnection(context.Background(), &commpb.CloseConnectionRequest{
+		ConnectionId: connect.GetConnectionId(),
+		Code:         4000,
+		Reason:       "bye",
+	}); err != nil {
+		t.Fatalf("Failed to close connection: %v", err)
+	}
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, 4000) {
+		t.Errorf("Expected close code 4000, got %v", err)
+	}
+
+	select {
+	case disconnect := <-events:
+		if disconnect.GetType() != WebSocketDisconnect || disconnect.GetConnectionId() != connect.GetConnectionId() {
+			t.Errorf("Expected disconnect event on connection %s, got %v", connect.GetConnectionId(), disconnect)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected disconnect action to be invoked")
+	}
+
+	_, err = svc.PostToConnection(context.Background(), &commpb.PostToConnectionRequest{ConnectionId: connect.GetConnectionId()})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for a closed connection, got %v", err)
+	}
+}
+
+func TestServer_WebSocket_ConnectRejected(t *testing.T) {
+	commClient := &MockCommunicationClient{
+		SendActionFunc: func(ctx context.Context, req *commpb.ExecuteRequest) (*commpb.ExecuteResponse, error) {
+			if req.GetAction() != "chat.connect" {
+				t.Errorf("Expected only the connect action, got %s", req.GetAction())
+			}
+			return &commpb.ExecuteResponse{
+				Resp: []byte("not allowed"),
+				Http: &pb.HttpResponse{StatusCode: http.StatusForbidden},
+			}, nil
+		},
+	}
+	server := newTestServer(t, commClient, routeFiles(map[string]string{"chat.yml": chatRoute}))
+
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	_, resp, err := dialWebSocket(t, ts, "/chat")
+	if err == nil {
+		t.Fatal("Expected the connection to be rejected")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status code %d, got %v", http.StatusForbidden, resp)
+	}
+}
+
+func TestServer_WebSocket_Rejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		{
+			name: "plain request",
+			want: http.StatusUpgradeRequired,
+		},
+		{
+			name: "foreign origin",
+			header: http.Header{
+				"Connection":            {"Upgrade"},
+				"Upgrade":               {"websocket"},
+				"Sec-Websocket-Version": {"13"},
+				"Sec-Websocket-Key":     {"dGhlIHNhbXBsZSBub25jZQ=="},
+				"Origin":                {"https://evil.example.com"},
+			},
+			want: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, &MockCommunicationClient{}, routeFiles(map[string]string{"chat.yml": chatRoute}))
+
+			req := httptest.NewRequest(http.MethodGet, "/chat", nil)
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			w := httptest.NewRecorder()
+			server.handleAction(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("Expected status code %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package sigil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	"github.com/Ow1Dev/NoctiFunc/pkg/communication"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// ErrConnectionNotFound is returned when the WebSocket connection is no
+// longer open, usually because the client went away.
+var ErrConnectionNotFound = errors.New("connection not found")
+
+// connectionClient connects to the connection API igniterelay serves to
+// functions, once per process.
+var connectionClient = sync.OnceValues(func() (commpb.ConnectionServiceClient, error) {
+	addr := os.Getenv(communication.RelayAddrEnv)
+	if addr == "" {
+		return nil, fmt.Errorf("%s is not set", communication.RelayAddrEnv)
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to igniterelay: %w", err)
+	}
+	return commpb.NewConnectionServiceClient(conn), nil
+})
+
+// PostToConnection sends data to a WebSocket connection as a text message.
+// data must be valid UTF-8.
+func PostToConnection(ctx context.Context, connectionID string, data []byte) error {
+	return postToConnection(ctx, connectionID, data, false)
+}
+
+// PostBinaryToConnection sends data to a WebSocket connection as a binary message.
+func PostBinaryToConnection(ctx context.Context, connectionID string, data []byte) error {
+	return postToConnection(ctx, connectionID, data, true)
+}
+
+func postToConnection(ctx context.Context, connectionID string, data []byte, binary bool) error {
+	client, err := connectionClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.PostToConnection(ctx, &commpb.PostToConnectionRequest{
+		ConnectionId: connectionID,
+		Data:         data,
+		Binary:       binary,
+	})
+	return connectionError(connectionID, err)
+}
+
+// CloseConnection closes a WebSocket connection. A zero code closes it
+// normally (1000); other codes must be between 3000 and 4999.
+func CloseConnection(ctx context.Context, connectionID string, code int, reason string) error {
+	client, err := connectionClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.CloseConnection(ctx, &commpb.CloseConnectionRequest{
+		ConnectionId: connectionID,
+		Code:         int32(code),
+		Reason:       reason,
+	})
+	return connectionError(connectionID, err)
+}
+
+func connectionError(connectionID string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if status.Code(err) == codes.NotFound {
+		return fmt.Errorf("%w: %s", ErrConnectionNotFound, connectionID)
+	}
+	return fmt.Errorf("connection %s: %w", connectionID, err)
+}
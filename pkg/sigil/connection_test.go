@@ -0,0 +1,77 @@
+package sigil
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	commpb "github.com/Ow1Dev/NoctiFunc/pkg/api/communication"
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeConnectionClient records the posts a function makes and knows a single connection.
+type fakeConnectionClient struct {
+	commpb.ConnectionServiceClient
+	posts []*commpb.PostToConnectionRequest
+}
+
+func (c *fakeConnectionClient) PostToConnection(_ context.Context, in *commpb.PostToConnectionRequest, _ ...grpc.CallOption) (*commpb.PostToConnectionResponse, error) {
+	if in.GetConnectionId() != "conn-1" {
+		return nil, status.Error(codes.NotFound, "unknown connection")
+	}
+	c.posts = append(c.posts, in)
+	return &commpb.PostToConnectionResponse{}, nil
+}
+
+func useConnectionClient(t *testing.T, client commpb.ConnectionServiceClient) {
+	t.Helper()
+	previous := connectionClient
+	connectionClient = func() (commpb.ConnectionServiceClient, error) { return client, nil }
+	t.Cleanup(func() { connectionClient = previous })
+}
+
+func TestServiceServer_InvokeWebSocketMessage(t *testing.T) {
+	client := &fakeConnectionClient{}
+	useConnectionClient(t, client)
+
+	srv := &serviceServer{handler: newHandler(func(ctx context.Context, msg struct{ Text string }) error {
+		return PostToConnection(ctx, ConnectionID(ctx), []byte("got "+msg.Text))
+	})}
+
+	_, err := srv.Invoke(context.Background(), &pb.InvokeRequest{
+		Payload: []byte(`{"Text":"hi"}`),
+		Http: &pb.HttpRequest{
+			Method:    "GET",
+			Websocket: &pb.WebSocketEvent{ConnectionId: "conn-1", Type: "message"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(client.posts) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(client.posts))
+	}
+	if string(client.posts[0].GetData()) != "got hi" || client.posts[0].GetBinary() {
+		t.Errorf("unexpected post %v", client.posts[0])
+	}
+}
+
+func TestPostToConnection_NotFound(t *testing.T) {
+	useConnectionClient(t, &fakeConnectionClient{})
+
+	err := PostBinaryToConnection(context.Background(), "gone", []byte{1})
+	if !errors.Is(err, ErrConnectionNotFound) {
+		t.Errorf("expected ErrConnectionNotFound, got %v", err)
+	}
+}
+
+func TestConnectionID_OutsideWebSocket(t *testing.T) {
+	ctx := withHTTPRequest(context.Background(), newHTTPRequest(&pb.HttpRequest{Method: "GET"}, nil))
+	if id := ConnectionID(ctx); id != "" {
+		t.Errorf("expected no connection ID, got %q", id)
+	}
+}
@@ -0,0 +1,63 @@
+package sigil
+
+import (
+	"context"
+
+	"github.com/Ow1Dev/NoctiFunc/pkg/communication"
+)
+
+type httpRequestKey struct{}
+
+func withHTTPRequest(ctx context.Context, req *HTTPRequest) context.Context {
+	return context.WithValue(ctx, httpRequestKey{}, req)
+}
+
+// HTTPRequestFromContext returns the HTTP request that invoked the function, if any.
+func HTTPRequestFromContext(ctx context.Context) (*HTTPRequest, bool) {
+	req, ok := ctx.Value(httpRequestKey{}).(*HTTPRequest)
+	return req, ok
+}
+
+// Method returns the HTTP method of the request that invoked the function.
+func Method(ctx context.Context) string {
+	if req, ok := HTTPRequestFromContext(ctx); ok {
+		return req.Method
+	}
+	return ""
+}
+
+// PathParams returns the path parameters captured by the route that invoked the function.
+func PathParams(ctx context.Context) map[string]string {
+	if req, ok := HTTPRequestFromContext(ctx); ok {
+		return req.PathParams
+	}
+	return nil
+}
+
+// PathParam returns the named path parameter, or an empty string if it was not captured.
+func PathParam(ctx context.Context, name string) string {
+	return PathParams(ctx)[name]
+}
+
+// IdentityFromContext returns the caller Prism authenticated, or nil on public routes.
+func IdentityFromContext(ctx context.Context) *Identity {
+	if req, ok := HTTPRequestFromContext(ctx); ok {
+		return req.Identity
+	}
+	return nil
+}
+
+// ConnectionID returns the ID of the WebSocket connection that invoked the
+// function, or an empty string outside websocket routes.
+func ConnectionID(ctx context.Context) string {
+	if req, ok := HTTPRequestFromContext(ctx); ok && req.WebSocket != nil {
+		return req.WebSocket.ConnectionID
+	}
+	return ""
+}
+
+// RequestID returns the ID Prism gave the request that invoked the function.
+// It is echoed to the client in the X-Request-ID header.
+func RequestID(ctx context.Context) string {
+	return communication.RequestID(ctx)
+}
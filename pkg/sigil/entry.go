@@ -18,6 +18,13 @@ import "log"
 //	func (context.Context, TIn)
 //	func (context.Context, TIn) error
 //	func (context.Context, TIn) (TOut, error)
+//
+// TIn is decoded from the JSON request body, unless it is HTTPRequest or
+// *HTTPRequest, in which case it receives the full HTTP request. TOut is
+// encoded as JSON, unless it is an io.Reader, which is sent as is, an
+// HTTPResponse, which also sets the status code, headers and cookies, or a
+// channel of Event, string or []byte, whose values are sent as they arrive.
+// Readers and channels are streamed to clients on routes with stream: true.
 func Start(handler any) {
 	StartWithOptions(handler)
 }
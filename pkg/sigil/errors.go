@@ -0,0 +1,76 @@
+package sigil
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error is an error a handler returns to answer with a specific HTTP status.
+// Code is a short machine-readable identifier such as "order_not_found" and
+// Message is shown to the client. Any other error is reported as a 500.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+// NewError returns an Error with the given HTTP status, code and message.
+func NewError(statusCode int, code, message string) *Error {
+	return &Error{StatusCode: statusCode, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// toStatus converts a handler error into the gRPC status returned to igniterelay.
+func toStatus(err error) error {
+	var fnErr *Error
+	if !errors.As(err, &fnErr) {
+		return status.Error(codes.Unknown, err.Error())
+	}
+
+	st, detailErr := status.New(grpcCode(fnErr.StatusCode), fnErr.Message).WithDetails(&pb.ErrorDetail{
+		HttpStatus: int32(fnErr.StatusCode),
+		Code:       fnErr.Code,
+		Message:    fnErr.Message,
+	})
+	if detailErr != nil {
+		return status.Error(grpcCode(fnErr.StatusCode), fnErr.Message)
+	}
+	return st.Err()
+}
+
+// grpcCode picks the gRPC code closest to an HTTP status.
+func grpcCode(statusCode int) codes.Code {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	if statusCode >= 400 && statusCode < 500 {
+		return codes.FailedPrecondition
+	}
+	return codes.Internal
+}
@@ -0,0 +1,72 @@
+package sigil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func errorDetail(t *testing.T, err error) *pb.ErrorDetail {
+	t.Helper()
+	for _, detail := range status.Convert(err).Details() {
+		if d, ok := detail.(*pb.ErrorDetail); ok {
+			return d
+		}
+	}
+	return nil
+}
+
+func TestServiceServer_InvokeUserError(t *testing.T) {
+	srv := &serviceServer{handler: newHandler(func() error {
+		return fmt.Errorf("lookup failed: %w", NewError(http.StatusNotFound, "order_not_found", "order 42 does not exist"))
+	})}
+
+	_, err := srv.Invoke(context.Background(), &pb.InvokeRequest{})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected code %s, got %s", codes.NotFound, status.Code(err))
+	}
+
+	d := errorDetail(t, err)
+	if d == nil {
+		t.Fatal("expected error detail")
+	}
+	if d.GetHttpStatus() != http.StatusNotFound || d.GetCode() != "order_not_found" || d.GetMessage() != "order 42 does not exist" {
+		t.Errorf("unexpected error detail: %v", d)
+	}
+}
+
+func TestServiceServer_InvokeDecodeError(t *testing.T) {
+	srv := &serviceServer{handler: newHandler(func(in TestInput) error {
+		return nil
+	})}
+
+	_, err := srv.Invoke(context.Background(), &pb.InvokeRequest{Payload: []byte("{not json")})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected code %s, got %s", codes.InvalidArgument, status.Code(err))
+	}
+
+	d := errorDetail(t, err)
+	if d == nil || d.GetHttpStatus() != http.StatusBadRequest || d.GetCode() != "invalid_payload" {
+		t.Errorf("unexpected error detail: %v", d)
+	}
+}
+
+func TestServiceServer_InvokePlainError(t *testing.T) {
+	srv := &serviceServer{handler: newHandler(func() error {
+		return errors.New("boom")
+	})}
+
+	_, err := srv.Invoke(context.Background(), &pb.InvokeRequest{})
+	if status.Code(err) != codes.Unknown {
+		t.Errorf("expected code %s, got %s", codes.Unknown, status.Code(err))
+	}
+	if errorDetail(t, err) != nil {
+		t.Error("expected no error detail")
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"net"
-	"os"
 
 	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
 	"google.golang.org/grpc"
@@ -20,23 +19,57 @@ type serviceServer struct {
 }
 
 func (s *serviceServer) Invoke(ctx context.Context, req *pb.InvokeRequest) (*pb.InvokeResult, error) {
-	fmt.Printf("[Invoke] Received request: %s\n", req.GetPayload())
+	log := Logger(ctx)
+	log.Info().Int("bytes", len(req.GetPayload())).Str("content_type", req.GetContentType()).Msg("Received request")
 
-	resp, err := s.handler.Invoke(ctx, []byte(req.GetPayload()))
+	payload := req.GetPayload()
+	ctx = withHTTPRequest(ctx, newHTTPRequest(req.GetHttp(), payload))
+
+	resp, err := s.handler.invoke(ctx, payload)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "[Invoke] Error invoking handler: %v\n", err)
-		return nil, fmt.Errorf("failed to invoke handler: %w", err)
+		log.Error().Err(err).Msg("Error invoking handler")
+		return nil, toStatus(err)
+	}
+
+	log.Info().Int("bytes", len(resp.body)).Str("content_type", resp.contentType).Msg("Sending response")
+
+	result := &pb.InvokeResult{
+		Output:      resp.body,
+		ContentType: resp.contentType,
+	}
+	if resp.http != nil {
+		result.Http = resp.http.toProto()
 	}
+	return result, nil
+}
+
+// InvokeStream runs the handler and sends its output to the caller as it is produced.
+func (s *serviceServer) InvokeStream(req *pb.InvokeRequest, stream pb.FunctionRunnerService_InvokeStreamServer) error {
+	log := Logger(stream.Context())
+	log.Info().Int("bytes", len(req.GetPayload())).Str("content_type", req.GetContentType()).Msg("Received streaming request")
 
-	fmt.Printf("[Invoke] Response: %s\n", resp)
+	payload := req.GetPayload()
+	ctx := withHTTPRequest(stream.Context(), newHTTPRequest(req.GetHttp(), payload))
 
-	return &pb.InvokeResult{
-		Output: string(resp),
-	}, nil
+	err := s.handler.stream(ctx, payload, func(data []byte, head *response) error {
+		chunk := &pb.InvokeChunk{Data: data}
+		if head != nil {
+			chunk.ContentType = head.contentType
+			if head.http != nil {
+				chunk.Http = head.http.toProto()
+			}
+		}
+		return stream.Send(chunk)
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Error invoking handler")
+		return toStatus(err)
+	}
+	return nil
 }
 
 // StartGRPCServer launches a gRPC server with the given handler on the specified port.
-func StartGRPCServer(handler handler, port int) error {
+func StartGRPCServer(handler handler, port int, opts ...grpc.ServerOption) error {
 	addr := fmt.Sprintf(":%d", port)
 
 	lis, err := net.Listen("tcp", addr)
@@ -44,10 +77,10 @@ func StartGRPCServer(handler handler, port int) error {
 		return fmt.Errorf("failed to listen on %s: %w", addr, err)
 	}
 
-	server := grpc.NewServer()
+	server := grpc.NewServer(opts...)
 	pb.RegisterFunctionRunnerServiceServer(server, &serviceServer{handler: handler})
 
-	fmt.Printf("[gRPC] Server listening on %s\n", addr)
+	logger.Info().Msgf("gRPC server listening on %s", addr)
 
 	if err := server.Serve(lis); err != nil {
 		return fmt.Errorf("gRPC server failed: %w", err)
@@ -57,6 +90,9 @@ func StartGRPCServer(handler handler, port int) error {
 }
 
 // startRuntimeGRPCLoop is a backward-compatible entry point that uses the default port.
-func startRuntimeGRPCLoop(handler handler) error {
-	return StartGRPCServer(handler, defaultGRPCPort)
+func startRuntimeGRPCLoop(handler *handlerOptions) error {
+	return StartGRPCServer(handler, defaultGRPCPort,
+		grpc.MaxRecvMsgSize(handler.maxMessageSize),
+		grpc.MaxSendMsgSize(handler.maxMessageSize),
+	)
 }
@@ -0,0 +1,223 @@
+package sigil
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServiceServer_InvokeRequestInfo(t *testing.T) {
+	srv := &serviceServer{handler: newHandler(func(ctx context.Context) (string, error) {
+		return Method(ctx) + " " + PathParam(ctx, "id"), nil
+	})}
+
+	resp, err := srv.Invoke(context.Background(), &pb.InvokeRequest{
+		Http: &pb.HttpRequest{
+			Method:     "GET",
+			PathParams: map[string]string{"id": "42"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(resp.GetOutput()) != "\"GET 42\"\n" {
+		t.Errorf("expected %q, got %q", "\"GET 42\"\n", resp.GetOutput())
+	}
+}
+
+func TestServiceServer_InvokeRequestID(t *testing.T) {
+	srv := &serviceServer{handler: newHandler(func(ctx context.Context) (string, error) {
+		return RequestID(ctx), nil
+	})}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", "req-1234"))
+	resp, err := srv.Invoke(ctx, &pb.InvokeRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(resp.GetOutput()) != "\"req-1234\"\n" {
+		t.Errorf("expected %q, got %q", "\"req-1234\"\n", resp.GetOutput())
+	}
+}
+
+func TestServiceServer_InvokeHTTPRequest(t *testing.T) {
+	var got HTTPRequest
+	srv := &serviceServer{handler: newHandler(func(req HTTPRequest) error {
+		got = req
+		return nil
+	})}
+
+	_, err := srv.Invoke(context.Background(), &pb.InvokeRequest{
+		Payload: []byte("hello"),
+		Http: &pb.HttpRequest{
+			Method:   "POST",
+			Path:     "/users/42",
+			RawQuery: "page=2&tag=a&tag=b",
+			Headers: map[string]*pb.HeaderValues{
+				"Cookie":       {Values: []string{"session=abc; theme=dark"}},
+				"X-Custom":     {Values: []string{"one"}},
+				"Content-Type": {Values: []string{"text/plain"}},
+			},
+			RemoteAddr: "10.0.0.1:1234",
+			Host:       "api.example.com",
+			PathParams: map[string]string{"id": "42"},
+			Identity:   &pb.Identity{Scheme: "api_key", ApiKeyId: "ci"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Identity == nil || got.Identity.APIKeyID != "ci" {
+		t.Errorf("expected API key ID ci, got %+v", got.Identity)
+	}
+	if got.Method != "POST" || got.Path != "/users/42" || got.Host != "api.example.com" || got.RemoteAddr != "10.0.0.1:1234" {
+		t.Errorf("unexpected request: %+v", got)
+	}
+	if string(got.Body) != "hello" {
+		t.Errorf("expected body %q, got %q", "hello", got.Body)
+	}
+	if got.PathParam("id") != "42" {
+		t.Errorf("expected path param id=42, got %q", got.PathParam("id"))
+	}
+	if got.Header.Get("X-Custom") != "one" {
+		t.Errorf("expected header X-Custom=one, got %q", got.Header.Get("X-Custom"))
+	}
+
+	query := got.Query()
+	if query.Get("page") != "2" || len(query["tag"]) != 2 {
+		t.Errorf("unexpected query: %v", query)
+	}
+
+	cookie, err := got.Cookie("theme")
+	if err != nil || cookie.Value != "dark" {
+		t.Errorf("expected cookie theme=dark, got %v (%v)", cookie, err)
+	}
+	if len(got.Cookies()) != 2 {
+		t.Errorf("expected 2 cookies, got %d", len(got.Cookies()))
+	}
+}
+
+func TestServiceServer_InvokeHTTPResponse(t *testing.T) {
+	srv := &serviceServer{handler: newHandler(func() (*HTTPResponse, error) {
+		return &HTTPResponse{
+			StatusCode: 201,
+			Header:     map[string][]string{"content-type": {"text/plain"}},
+			Cookies:    []*http.Cookie{{Name: "session", Value: "abc", Path: "/"}},
+			Body:       []byte("created"),
+		}, nil
+	})}
+
+	resp, err := srv.Invoke(context.Background(), &pb.InvokeRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(resp.GetOutput()) != "created" {
+		t.Errorf("expected output %q, got %q", "created", resp.GetOutput())
+	}
+	if resp.GetHttp().GetStatusCode() != 201 {
+		t.Errorf("expected status 201, got %d", resp.GetHttp().GetStatusCode())
+	}
+
+	headers := resp.GetHttp().GetHeaders()
+	if v := headers["Content-Type"].GetValues(); len(v) != 1 || v[0] != "text/plain" {
+		t.Errorf("expected Content-Type text/plain, got %v", v)
+	}
+	if v := headers["Set-Cookie"].GetValues(); len(v) != 1 || v[0] != "session=abc; Path=/" {
+		t.Errorf("expected session cookie, got %v", v)
+	}
+}
+
+func TestServiceServer_InvokeWithoutHTTPResponse(t *testing.T) {
+	srv := &serviceServer{handler: newHandler(func() (string, error) {
+		return "plain", nil
+	})}
+
+	resp, err := srv.Invoke(context.Background(), &pb.InvokeRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.GetHttp() != nil {
+		t.Errorf("expected no HTTP response metadata, got %v", resp.GetHttp())
+	}
+}
+
+func TestServiceServer_InvokeBinary(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\xff\xfe")
+
+	var got HTTPRequest
+	srv := &serviceServer{handler: newHandler(func(req HTTPRequest) (io.Reader, error) {
+		got = req
+		return bytes.NewReader(req.Body), nil
+	})}
+
+	resp, err := srv.Invoke(context.Background(), &pb.InvokeRequest{
+		Payload:     png,
+		ContentType: "image/png",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(got.Body, png) {
+		t.Errorf("expected body %q, got %q", png, got.Body)
+	}
+	if !bytes.Equal(resp.GetOutput(), png) {
+		t.Errorf("expected output %q, got %q", png, resp.GetOutput())
+	}
+	if resp.GetContentType() != "image/png" {
+		t.Errorf("expected content type image/png, got %q", resp.GetContentType())
+	}
+}
+
+func TestServiceServer_InvokeJSONContentType(t *testing.T) {
+	srv := &serviceServer{handler: newHandler(func() (string, error) {
+		return "plain", nil
+	})}
+
+	resp, err := srv.Invoke(context.Background(), &pb.InvokeRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(resp.GetOutput()) != "\"plain\"\n" {
+		t.Errorf("expected output %q, got %q", "\"plain\"\n", resp.GetOutput())
+	}
+	if resp.GetContentType() != "application/json" {
+		t.Errorf("expected content type application/json, got %q", resp.GetContentType())
+	}
+}
+
+func TestServiceServer_InvokeJWTIdentity(t *testing.T) {
+	var got *Identity
+	srv := &serviceServer{handler: newHandler(func(ctx context.Context) error {
+		got = IdentityFromContext(ctx)
+		return nil
+	})}
+
+	_, err := srv.Invoke(context.Background(), &pb.InvokeRequest{
+		Http: &pb.HttpRequest{
+			Identity: &pb.Identity{
+				Scheme:  "jwt",
+				Subject: "user-42",
+				Claims:  []byte(`{"sub":"user-42","scope":"orders:read"}`),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got == nil || got.Subject != "user-42" || got.Claims["scope"] != "orders:read" {
+		t.Errorf("unexpected identity: %+v", got)
+	}
+}
@@ -7,20 +7,33 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"reflect"
 	"sync"
+
+	"github.com/Ow1Dev/NoctiFunc/pkg/communication"
 )
 
 type handler interface {
 	Invoke(ctx context.Context, payload []byte) ([]byte, error)
+	invoke(ctx context.Context, payload []byte) (*response, error)
+	stream(ctx context.Context, payload []byte, send sendFunc) error
+}
+
+// response is the result of a single invocation.
+type response struct {
+	body        []byte
+	contentType string
+	http        *HTTPResponse // set when the handler returned an HTTPResponse
 }
 
 type handlerFunc func(context.Context, []byte) (io.Reader, error)
 
 type handlerOptions struct {
 	handlerFunc
-	baseContext context.Context
+	baseContext    context.Context
+	maxMessageSize int
 }
 
 type Option func(*handlerOptions)
@@ -32,6 +45,14 @@ func WithContext(ctx context.Context) Option {
 	}
 }
 
+// WithMaxMessageSize sets the largest gRPC message the runtime sends or
+// receives. It should match the -max-body-bytes limit of igniterelay.
+func WithMaxMessageSize(maxBodyBytes int64) Option {
+	return func(h *handlerOptions) {
+		h.maxMessageSize = communication.MaxMessageSize(maxBodyBytes)
+	}
+}
+
 func newHandlerWithOptions(handlerFunc any, options ...Option) handler {
 	return newHandler(handlerFunc, options...)
 }
@@ -43,7 +64,8 @@ func newHandler(fn any, opts ...Option) *handlerOptions {
 	}
 
 	h := &handlerOptions{
-		baseContext: context.Background(),
+		baseContext:    context.Background(),
+		maxMessageSize: communication.MaxMessageSize(communication.DefaultMaxBodyBytes),
 	}
 
 	for _, opt := range opts {
@@ -55,29 +77,70 @@ func newHandler(fn any, opts ...Option) *handlerOptions {
 }
 
 func (h handlerFunc) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
-	resp, err := h(ctx, payload)
+	resp, err := h.invoke(ctx, payload)
 	if err != nil {
 		return nil, err
 	}
+	return resp.body, nil
+}
 
-	// Cleanup resources if response implements io.Closer
-	if closer, ok := resp.(io.Closer); ok {
-		defer func() {
-			if err := closer.Close(); err != nil {
-				fmt.Fprintf(os.Stderr, "error closing response: %v\n", err)
-			}
-		}()
+func (h handlerFunc) invoke(ctx context.Context, payload []byte) (*response, error) {
+	out, err := h(ctx, payload)
+	if err != nil {
+		return nil, err
 	}
+	defer closeOutput(out)
 
-	// Fast-path if it's already a bytes.Buffer or jsonOutBuffer
-	switch b := resp.(type) {
+	if resp, ok := bufferedResponse(out); ok {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(out)
+	if err != nil {
+		return nil, err
+	}
+	contentType := detectContentType(body)
+	if ch, ok := out.(*channelReader); ok && ch.contentType != "" {
+		contentType = ch.contentType
+	}
+	return &response{body: body, contentType: contentType}, nil
+}
+
+// closeOutput cleans up resources if the handler output implements io.Closer.
+func closeOutput(out io.Reader) {
+	if closer, ok := out.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "error closing response: %v\n", err)
+		}
+	}
+}
+
+// bufferedResponse returns the response for outputs that are already held in
+// memory, which do not need to be read incrementally.
+func bufferedResponse(out io.Reader) (*response, bool) {
+	switch b := out.(type) {
+	case *httpResponseReader:
+		return &response{body: b.response.Body, contentType: b.response.Header.Get("Content-Type"), http: b.response}, true
+	case *jsonOutBufferReader:
+		// The buffer returns to the pool on Close, so keep a copy of its contents
+		return &response{body: bytes.Clone(b.Bytes()), contentType: jsonContentType}, true
 	case *jsonOutBuffer:
-		return b.Bytes(), nil
+		return &response{body: b.Bytes(), contentType: jsonContentType}, true
 	case *bytes.Buffer:
-		return b.Bytes(), nil
+		return &response{body: b.Bytes(), contentType: detectContentType(b.Bytes())}, true
 	default:
-		return io.ReadAll(resp)
+		return nil, false
+	}
+}
+
+const jsonContentType = "application/json"
+
+// detectContentType sniffs the content type of raw handler output.
+func detectContentType(body []byte) string {
+	if len(body) == 0 {
+		return ""
 	}
+	return http.DetectContentType(body)
 }
 
 func errorHandler(err error) handlerFunc {
@@ -161,6 +224,11 @@ func putBuffer(buf *jsonOutBuffer) {
 	}
 }
 
+var (
+	httpRequestType    = reflect.TypeOf(HTTPRequest{})
+	httpRequestPtrType = reflect.TypeOf(&HTTPRequest{})
+)
+
 // wrapHandler converts a user-defined function to a handlerFunc.
 func wrapHandler(fn any) handlerFunc {
 	if fn == nil {
@@ -201,14 +269,29 @@ func wrapHandler(fn any) handlerFunc {
 		// Add input parameter if required
 		if paramIndex < typ.NumIn() {
 			eventType := typ.In(paramIndex)
-			event := reflect.New(eventType)
 
-			if len(payload) > 0 {
-				if err := json.Unmarshal(payload, event.Interface()); err != nil {
-					return nil, fmt.Errorf("failed to decode input: %w", err)
+			switch eventType {
+			case httpRequestType, httpRequestPtrType:
+				// HTTP request events are built from the invocation rather than decoded
+				req, ok := HTTPRequestFromContext(ctx)
+				if !ok {
+					req = &HTTPRequest{Body: payload}
+				}
+				if eventType == httpRequestPtrType {
+					args = append(args, reflect.ValueOf(req))
+				} else {
+					args = append(args, reflect.ValueOf(*req))
 				}
+			default:
+				event := reflect.New(eventType)
+
+				if len(payload) > 0 {
+					if err := json.Unmarshal(payload, event.Interface()); err != nil {
+						return nil, NewError(http.StatusBadRequest, "invalid_payload", "failed to decode input: "+err.Error())
+					}
+				}
+				args = append(args, event.Elem())
 			}
-			args = append(args, event.Elem())
 		}
 
 		// Invoke the function
@@ -217,7 +300,7 @@ func wrapHandler(fn any) handlerFunc {
 		// Handle return values
 		if len(results) == 0 {
 			// No return values - return null
-			return bytes.NewReader([]byte("null")), nil
+			return nullResponse(), nil
 		}
 
 		// Check for error (always last return value if present)
@@ -238,7 +321,7 @@ func wrapHandler(fn any) handlerFunc {
 			// Check if single return is error or value
 			if results[0].Type().Implements(reflect.TypeOf((*error)(nil)).Elem()) {
 				// Single error return - no output value
-				return bytes.NewReader([]byte("null")), nil
+				return nullResponse(), nil
 			} else {
 				// Single value return (shouldn't happen with current validation, but handle gracefully)
 				resp = results[0].Interface()
@@ -250,6 +333,21 @@ func wrapHandler(fn any) handlerFunc {
 			return reader, nil
 		}
 
+		// Channels stream their values as they are sent
+		if ch := reflect.ValueOf(resp); ch.Kind() == reflect.Chan {
+			return newChannelReader(ctx, ch)
+		}
+
+		// HTTP responses carry their own body, status and headers
+		switch r := resp.(type) {
+		case HTTPResponse:
+			return newHTTPResponseReader(&r), nil
+		case *HTTPResponse:
+			if r != nil {
+				return newHTTPResponseReader(r), nil
+			}
+		}
+
 		// JSON encode the response
 		buf := getBuffer()
 		encoder := json.NewEncoder(buf)
@@ -263,6 +361,11 @@ func wrapHandler(fn any) handlerFunc {
 	}
 }
 
+// nullResponse is the JSON output of handlers without a return value.
+func nullResponse() io.Reader {
+	return &jsonOutBuffer{Buffer: bytes.NewBufferString("null")}
+}
+
 // jsonOutBufferReader wraps jsonOutBuffer to handle proper cleanup
 type jsonOutBufferReader struct {
 	*jsonOutBuffer
@@ -279,3 +382,14 @@ func (r *jsonOutBufferReader) Close() error {
 
 // Ensure jsonOutBufferReader implements io.ReadCloser
 var _ io.ReadCloser = (*jsonOutBufferReader)(nil)
+
+// httpResponseReader reads the body of an HTTPResponse while keeping its
+// status and headers available to the runtime.
+type httpResponseReader struct {
+	*bytes.Reader
+	response *HTTPResponse
+}
+
+func newHTTPResponseReader(resp *HTTPResponse) *httpResponseReader {
+	return &httpResponseReader{Reader: bytes.NewReader(resp.Body), response: resp}
+}
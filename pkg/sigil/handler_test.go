@@ -456,3 +456,55 @@ func TestContextPassing(t *testing.T) {
 		t.Errorf("expected %+v, got %+v", expected, output)
 	}
 }
+
+func TestHTTPRequestInput(t *testing.T) {
+	handler := newHandler(func(req *HTTPRequest) (string, error) {
+		return req.Method + " " + string(req.Body), nil
+	})
+
+	ctx := withHTTPRequest(context.Background(), &HTTPRequest{Method: "PUT", Body: []byte("raw")})
+	result, err := handler.Invoke(ctx, []byte("raw"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(result) != "\"PUT raw\"\n" {
+		t.Errorf("expected %q, got %q", "\"PUT raw\"\n", string(result))
+	}
+
+	// Without an HTTP request in the context the payload is still available
+	result, err = handler.Invoke(context.Background(), []byte("not json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(result) != "\" not json\"\n" {
+		t.Errorf("expected %q, got %q", "\" not json\"\n", string(result))
+	}
+}
+
+func TestHTTPResponseOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   any
+	}{
+		{"value", func() (HTTPResponse, error) { return HTTPResponse{StatusCode: 202, Body: []byte("accepted")}, nil }},
+		{"pointer", func() (*HTTPResponse, error) { return &HTTPResponse{StatusCode: 202, Body: []byte("accepted")}, nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := newHandler(tt.fn).invoke(context.Background(), nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(resp.body) != "accepted" {
+				t.Errorf("expected body %q, got %q", "accepted", string(resp.body))
+			}
+			if resp.http == nil || resp.http.StatusCode != 202 {
+				t.Errorf("expected status 202, got %+v", resp.http)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package sigil
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+)
+
+// HTTPRequest is the HTTP request that triggered an invocation. Handlers
+// receive it by declaring it (or a pointer to it) as their TIn.
+type HTTPRequest struct {
+	Method     string
+	Path       string
+	RawQuery   string
+	Header     http.Header
+	RemoteAddr string
+	Host       string
+	PathParams map[string]string
+	Identity   *Identity       // nil on public routes
+	WebSocket  *WebSocketEvent // nil outside websocket routes
+	Body       []byte
+}
+
+// WebSocketEvent describes the event on a WebSocket connection that invoked
+// the function. For message events the message is the request body.
+type WebSocketEvent struct {
+	ConnectionID string
+	Type         string // "connect", "message" or "disconnect"
+	Binary       bool   // set when the message arrived in a binary frame
+}
+
+// Identity is the caller Prism authenticated for the route.
+type Identity struct {
+	Scheme   string         // "api_key" or "jwt"
+	APIKeyID string         // ID of the API key used, for api_key routes
+	Subject  string         // "sub" claim of the token, for jwt routes
+	Claims   map[string]any // validated token claims, for jwt routes
+}
+
+// Query parses RawQuery and returns the query parameters.
+func (r *HTTPRequest) Query() url.Values {
+	values, _ := url.ParseQuery(r.RawQuery)
+	return values
+}
+
+// PathParam returns the named path parameter, or an empty string if it was not captured.
+func (r *HTTPRequest) PathParam(name string) string {
+	return r.PathParams[name]
+}
+
+// Cookies parses and returns the cookies sent with the request.
+func (r *HTTPRequest) Cookies() []*http.Cookie {
+	return (&http.Request{Header: r.Header}).Cookies()
+}
+
+// Cookie returns the named cookie or http.ErrNoCookie if it was not sent.
+func (r *HTTPRequest) Cookie(name string) (*http.Cookie, error) {
+	return (&http.Request{Header: r.Header}).Cookie(name)
+}
+
+// HTTPResponse lets a handler control the HTTP response sent to the client.
+// A zero StatusCode means 200, and without a Content-Type header the body is
+// sent as application/json.
+type HTTPResponse struct {
+	StatusCode int
+	Header     http.Header
+	Cookies    []*http.Cookie
+	Body       []byte
+}
+
+func (r *HTTPResponse) toProto() *pb.HttpResponse {
+	headers := make(map[string]*pb.HeaderValues, len(r.Header)+1)
+	for key, values := range r.Header {
+		headers[http.CanonicalHeaderKey(key)] = &pb.HeaderValues{Values: values}
+	}
+
+	for _, cookie := range r.Cookies {
+		if v := cookie.String(); v != "" {
+			if headers["Set-Cookie"] == nil {
+				headers["Set-Cookie"] = &pb.HeaderValues{}
+			}
+			headers["Set-Cookie"].Values = append(headers["Set-Cookie"].Values, v)
+		}
+	}
+
+	return &pb.HttpResponse{
+		StatusCode: int32(r.StatusCode),
+		Headers:    headers,
+	}
+}
+
+func newHTTPRequest(event *pb.HttpRequest, body []byte) *HTTPRequest {
+	header := make(http.Header, len(event.GetHeaders()))
+	for key, values := range event.GetHeaders() {
+		header[key] = values.GetValues()
+	}
+
+	req := &HTTPRequest{
+		Method:     event.GetMethod(),
+		Path:       event.GetPath(),
+		RawQuery:   event.GetRawQuery(),
+		Header:     header,
+		RemoteAddr: event.GetRemoteAddr(),
+		Host:       event.GetHost(),
+		PathParams: event.GetPathParams(),
+		Body:       body,
+	}
+	if ws := event.GetWebsocket(); ws != nil {
+		req.WebSocket = &WebSocketEvent{
+			ConnectionID: ws.GetConnectionId(),
+			Type:         ws.GetType(),
+			Binary:       ws.GetBinary(),
+		}
+	}
+	if id := event.GetIdentity(); id != nil {
+		req.Identity = &Identity{
+			Scheme:   id.GetScheme(),
+			APIKeyID: id.GetApiKeyId(),
+			Subject:  id.GetSubject(),
+		}
+		if len(id.GetClaims()) > 0 {
+			// Prism only forwards claims it decoded from a verified token
+			_ = json.Unmarshal(id.GetClaims(), &req.Identity.Claims)
+		}
+	}
+	return req
+}
@@ -0,0 +1,23 @@
+package sigil
+
+import (
+	"context"
+	"os"
+
+	"github.com/rs/zerolog"
+)
+
+// logger writes the runtime's log lines to stdout as JSON.
+var logger = zerolog.New(os.Stdout).With().Timestamp().Logger()
+
+// Logger returns a logger that adds the ID of the request that invoked the
+// function, so the function's log lines can be matched with Prism's and
+// igniterelay's.
+func Logger(ctx context.Context) *zerolog.Logger {
+	id := RequestID(ctx)
+	if id == "" {
+		return &logger
+	}
+	l := logger.With().Str("request_id", id).Logger()
+	return &l
+}
@@ -0,0 +1,203 @@
+package sigil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const eventStreamContentType = "text/event-stream"
+
+// streamChunkSize is the most output sent in one chunk of a streamed invocation.
+const streamChunkSize = 32 << 10
+
+// Event is a Server-Sent Event. Handlers stream events by returning a channel
+// of them, which Prism relays to the client as text/event-stream.
+type Event struct {
+	ID    string        // sets the client's last event ID
+	Event string        // event type, empty for "message"
+	Data  any           // strings and byte slices are sent as is, other values as JSON
+	Retry time.Duration // reconnection delay for the client, 0 keeps its default
+}
+
+// eventFieldReplacer keeps line breaks in IDs and event types from starting new fields.
+var eventFieldReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+// marshal encodes e in the text/event-stream format.
+func (e Event) marshal() ([]byte, error) {
+	var b bytes.Buffer
+	if e.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", eventFieldReplacer.Replace(e.ID))
+	}
+	if e.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", eventFieldReplacer.Replace(e.Event))
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", e.Retry.Milliseconds())
+	}
+
+	var data []byte
+	switch d := e.Data.(type) {
+	case nil:
+	case string:
+		data = []byte(d)
+	case []byte:
+		data = d
+	default:
+		var err error
+		if data, err = json.Marshal(d); err != nil {
+			return nil, fmt.Errorf("event encoding failed: %w", err)
+		}
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		b.WriteString("data: ")
+		b.Write(bytes.TrimSuffix(line, []byte("\r")))
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+var eventType = reflect.TypeOf(Event{})
+
+// channelReader reads the values a handler sends on its channel until the
+// channel is closed. Events are encoded as Server-Sent Events, strings and
+// byte slices are passed through as is. Each Read returns data from a single
+// value, so every value is streamed as soon as it is sent.
+type channelReader struct {
+	cases       []reflect.SelectCase
+	ctx         context.Context
+	contentType string
+	encode      func(reflect.Value) ([]byte, error)
+	pending     []byte
+}
+
+func newChannelReader(ctx context.Context, ch reflect.Value) (io.Reader, error) {
+	if ch.Type().ChanDir()&reflect.RecvDir == 0 {
+		return nil, fmt.Errorf("handler returned a send-only channel: %v", ch.Type())
+	}
+
+	r := &channelReader{
+		cases: []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: ch},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		},
+		ctx: ctx,
+	}
+
+	switch elem := ch.Type().Elem(); {
+	case elem == eventType:
+		r.contentType = eventStreamContentType
+		r.encode = func(v reflect.Value) ([]byte, error) {
+			return v.Interface().(Event).marshal()
+		}
+	case elem.Kind() == reflect.String:
+		r.encode = func(v reflect.Value) ([]byte, error) {
+			return []byte(v.String()), nil
+		}
+	case elem.Kind() == reflect.Slice && elem.Elem().Kind() == reflect.Uint8:
+		r.encode = func(v reflect.Value) ([]byte, error) {
+			return v.Bytes(), nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported channel element type: %v", elem)
+	}
+
+	if ch.IsNil() {
+		// A nil channel never delivers a value, so treat it as an empty stream
+		r.cases = nil
+	}
+	return r, nil
+}
+
+func (r *channelReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.cases == nil {
+			return 0, io.EOF
+		}
+		chosen, v, ok := reflect.Select(r.cases)
+		if chosen == 1 {
+			return 0, r.ctx.Err()
+		}
+		if !ok {
+			r.cases = nil
+			return 0, io.EOF
+		}
+		data, err := r.encode(v)
+		if err != nil {
+			return 0, err
+		}
+		r.pending = data
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// sendFunc sends one chunk of streamed output. head describes the response
+// and is only set on the first chunk.
+type sendFunc func(data []byte, head *response) error
+
+// stream runs the handler and sends its output as it is produced. Outputs
+// held in memory are sent in chunks right away, while io.Readers and channels
+// are read incrementally.
+func (h handlerFunc) stream(ctx context.Context, payload []byte, send sendFunc) error {
+	out, err := h(ctx, payload)
+	if err != nil {
+		return err
+	}
+	defer closeOutput(out)
+
+	if resp, ok := bufferedResponse(out); ok {
+		head, body := resp, resp.body
+		for {
+			n := min(len(body), streamChunkSize)
+			if err := send(body[:n], head); err != nil {
+				return err
+			}
+			head, body = nil, body[n:]
+			if len(body) == 0 {
+				return nil
+			}
+		}
+	}
+
+	head := &response{}
+	if ch, ok := out.(*channelReader); ok {
+		head.contentType = ch.contentType
+	}
+
+	buf := make([]byte, streamChunkSize)
+	for {
+		n, err := out.Read(buf)
+		if n > 0 {
+			if head != nil && head.contentType == "" {
+				head.contentType = detectContentType(buf[:n])
+			}
+			// gRPC may still reference a sent message, so buf cannot be reused for it
+			if err := send(bytes.Clone(buf[:n]), head); err != nil {
+				return err
+			}
+			head = nil
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if head != nil {
+		// The output was empty, so only the response itself is sent
+		return send(nil, head)
+	}
+	return nil
+}
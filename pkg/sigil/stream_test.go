@@ -0,0 +1,165 @@
+package sigil
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/Ow1Dev/NoctiFunc/pkg/api/server"
+	"google.golang.org/grpc"
+)
+
+// fakeInvokeStream records the chunks sent on a server stream.
+type fakeInvokeStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	chunks []*pb.InvokeChunk
+}
+
+func (s *fakeInvokeStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeInvokeStream) Send(chunk *pb.InvokeChunk) error {
+	s.chunks = append(s.chunks, chunk)
+	return nil
+}
+
+func invokeStream(t *testing.T, ctx context.Context, fn any) (*fakeInvokeStream, error) {
+	t.Helper()
+
+	srv := &serviceServer{handler: newHandler(fn)}
+	stream := &fakeInvokeStream{ctx: ctx}
+	err := srv.InvokeStream(&pb.InvokeRequest{Http: &pb.HttpRequest{Method: "GET"}}, stream)
+	return stream, err
+}
+
+func TestServiceServer_InvokeStreamEvents(t *testing.T) {
+	stream, err := invokeStream(t, context.Background(), func(ctx context.Context) (<-chan Event, error) {
+		events := make(chan Event)
+		go func() {
+			defer close(events)
+			events <- Event{Event: "progress", Data: map[string]int{"percent": 50}}
+			events <- Event{ID: "2", Data: "done"}
+		}()
+		return events, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(stream.chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(stream.chunks))
+	}
+	if stream.chunks[0].GetContentType() != "text/event-stream" {
+		t.Errorf("expected content type text/event-stream, got %q", stream.chunks[0].GetContentType())
+	}
+	if stream.chunks[1].GetContentType() != "" {
+		t.Errorf("expected content type on the first chunk only, got %q", stream.chunks[1].GetContentType())
+	}
+	if got := string(stream.chunks[0].GetData()); got != "event: progress\ndata: {\"percent\":50}\n\n" {
+		t.Errorf("unexpected first event %q", got)
+	}
+	if got := string(stream.chunks[1].GetData()); got != "id: 2\ndata: done\n\n" {
+		t.Errorf("unexpected second event %q", got)
+	}
+}
+
+func TestServiceServer_InvokeStreamReader(t *testing.T) {
+	stream, err := invokeStream(t, context.Background(), func() (io.Reader, error) {
+		pr, pw := io.Pipe()
+		go func() {
+			for _, token := range []string{"Hello", ", ", "world"} {
+				_, _ = pw.Write([]byte(token))
+			}
+			_ = pw.Close()
+		}()
+		return pr, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var tokens []string
+	for _, chunk := range stream.chunks {
+		tokens = append(tokens, string(chunk.GetData()))
+	}
+	if strings.Join(tokens, "|") != "Hello|, |world" {
+		t.Errorf("expected each write as a chunk, got %q", tokens)
+	}
+	if !strings.HasPrefix(stream.chunks[0].GetContentType(), "text/plain") {
+		t.Errorf("expected sniffed text/plain content type, got %q", stream.chunks[0].GetContentType())
+	}
+}
+
+func TestServiceServer_InvokeStreamBuffered(t *testing.T) {
+	stream, err := invokeStream(t, context.Background(), func() (*HTTPResponse, error) {
+		return &HTTPResponse{StatusCode: 201, Body: []byte("created")}, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(stream.chunks) != 1 {
+		t.Fatalf("expected 1 chunk, got %d", len(stream.chunks))
+	}
+	if stream.chunks[0].GetHttp().GetStatusCode() != 201 || string(stream.chunks[0].GetData()) != "created" {
+		t.Errorf("unexpected chunk %v", stream.chunks[0])
+	}
+}
+
+func TestServiceServer_InvokeStreamCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := invokeStream(t, ctx, func() (chan string, error) {
+		return make(chan string), nil
+	})
+	if err == nil {
+		t.Fatal("expected error when the context ends before the channel is closed")
+	}
+}
+
+func TestServiceServer_InvokeChannel(t *testing.T) {
+	srv := &serviceServer{handler: newHandler(func() (<-chan string, error) {
+		tokens := make(chan string, 2)
+		tokens <- "Hello"
+		tokens <- " world"
+		close(tokens)
+		return tokens, nil
+	})}
+
+	resp, err := srv.Invoke(context.Background(), &pb.InvokeRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resp.GetOutput()) != "Hello world" {
+		t.Errorf("expected %q, got %q", "Hello world", resp.GetOutput())
+	}
+}
+
+func TestEvent_Marshal(t *testing.T) {
+	tests := []struct {
+		name  string
+		event Event
+		want  string
+	}{
+		{name: "multiline data", event: Event{Data: "one\ntwo"}, want: "data: one\ndata: two\n\n"},
+		{name: "retry", event: Event{Retry: 3 * time.Second, Data: []byte("x")}, want: "retry: 3000\ndata: x\n\n"},
+		{name: "line breaks in fields", event: Event{ID: "a\nb", Event: "c\r\nd", Data: "x"}, want: "id: ab\nevent: cd\ndata: x\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.event.marshal()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}